@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -10,9 +12,39 @@ var (
 )
 
 type Config struct {
-	JWTSecret    string
-	CorsOrigins  []string
-	CookieDomain string
+	// Token signing. HS256 signs with JWTSecret; EdDSA and RS256 sign with the
+	// PEM private key in JWTPrivateKeyFile. JWTVerifyKeys lists retired keys
+	// as "kid:value" pairs, where value is a secret for HS256 or the path to
+	// a PEM public key otherwise, so tokens they signed stay valid.
+	JWTAlgorithm      string
+	JWTKeyID          string
+	JWTSecret         string
+	JWTPrivateKeyFile string
+	JWTVerifyKeys     []string
+
+	CorsOrigins      []string
+	CookieDomain     string
+	RegistrationMode string // open, invite or closed
+	LoginMaxFailures int    // Failed logins per username before a lockout
+	LoginLockout     time.Duration
+	EliminationRules []string
+	UploadDir        string
+	MaxUploadSize    int64 // Bytes per evidence file
+	SimulatedClock   bool  // Development only: game time can be frozen and advanced
+
+	// Limits on submitted phrases
+	PhraseMinWords      int
+	PhraseMaxWords      int
+	PhraseMinChars      int
+	PhraseMaxChars      int
+	PhraseDuplicateDays int // How far back to look for near-duplicates
+
+	// Schedule used by games that haven't configured their own
+	DefaultTimezone  string
+	DefaultOpenStart string
+	DefaultOpenEnd   string
+	DefaultCloseAt   string // Empty closes windows at midnight
+	DefaultCadence   string
 }
 
 func LoadConfig() Config {
@@ -24,15 +56,7 @@ func LoadConfig() Config {
 	}
 
 	// Split the origins by comma
-	origins := strings.Split(corsOrigins, ",")
-	// Remove any empty strings and strip whitespace
-	for i := 0; i < len(origins); i++ {
-		origins[i] = strings.TrimSpace(origins[i])
-		if origins[i] == "" {
-			origins = append(origins[:i], origins[i+1:]...)
-			i--
-		}
-	}
+	origins := SplitList(corsOrigins)
 
 	// Print the origins
 	for _, origin := range origins {
@@ -40,10 +64,63 @@ func LoadConfig() Config {
 	}
 
 	return Config{
-		JWTSecret:    os.Getenv("JWT_SECRET"),
-		CorsOrigins:  origins,
-		CookieDomain: os.Getenv("COOKIE_DOMAIN"),
+		JWTAlgorithm:        getEnvDefault("JWT_ALGORITHM", "HS256"),
+		JWTKeyID:            getEnvDefault("JWT_KEY_ID", "default"),
+		JWTSecret:           os.Getenv("JWT_SECRET"),
+		JWTPrivateKeyFile:   os.Getenv("JWT_PRIVATE_KEY_FILE"),
+		JWTVerifyKeys:       SplitList(os.Getenv("JWT_VERIFY_KEYS")),
+		CorsOrigins:         origins,
+		CookieDomain:        os.Getenv("COOKIE_DOMAIN"),
+		RegistrationMode:    getEnvDefault("REGISTRATION_MODE", "open"),
+		LoginMaxFailures:    int(getEnvInt64("LOGIN_MAX_FAILURES", 10)),
+		LoginLockout:        time.Duration(getEnvInt64("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+		EliminationRules:    SplitList(getEnvDefault("ELIMINATION_RULES", "not_verified")),
+		UploadDir:           getEnvDefault("UPLOAD_DIR", "uploads"),
+		MaxUploadSize:       getEnvInt64("MAX_UPLOAD_SIZE", 10<<20),
+		SimulatedClock:      os.Getenv("SIMULATED_CLOCK") == "true",
+		PhraseMinWords:      int(getEnvInt64("PHRASE_MIN_WORDS", 2)),
+		PhraseMaxWords:      int(getEnvInt64("PHRASE_MAX_WORDS", 15)),
+		PhraseMinChars:      int(getEnvInt64("PHRASE_MIN_CHARS", 5)),
+		PhraseMaxChars:      int(getEnvInt64("PHRASE_MAX_CHARS", 120)),
+		PhraseDuplicateDays: int(getEnvInt64("PHRASE_DUPLICATE_DAYS", 30)),
+		DefaultTimezone:     getEnvDefault("DEFAULT_TIMEZONE", "America/Chicago"),
+		DefaultOpenStart:    getEnvDefault("DEFAULT_OPEN_START", "04:30"),
+		DefaultOpenEnd:      getEnvDefault("DEFAULT_OPEN_END", "08:20"),
+		DefaultCloseAt:      os.Getenv("DEFAULT_CLOSE_AT"),
+		DefaultCadence:      getEnvDefault("DEFAULT_CADENCE", "daily"),
+	}
+}
+
+// getEnvInt64 returns the env var parsed as an integer or fallback when it is
+// unset or invalid
+func getEnvInt64(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvDefault returns the env var or fallback when it is unset
+func getEnvDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// SplitList splits a comma separated value, stripping whitespace and
+// dropping empty entries.
+func SplitList(value string) []string {
+	items := strings.Split(value, ",")
+	for i := 0; i < len(items); i++ {
+		items[i] = strings.TrimSpace(items[i])
+		if items[i] == "" {
+			items = append(items[:i], items[i+1:]...)
+			i--
+		}
 	}
+	return items
 }
 
 func GetConfig() Config {
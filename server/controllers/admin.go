@@ -1,45 +1,60 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/bluefalconhd/lbd_game/server/database"
 	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
 	"github.com/gin-gonic/gin"
 )
 
 func GetUserStatistics(c *gin.Context) {
-	var users []models.User
-	if err := database.DB.Find(&users).Error; err != nil {
+	gameID := c.GetUint("gameID")
+
+	received := database.DB.Table("verifications").
+		Select("verified_user_id as user_id, COUNT(*) as total").
+		Where("game_id = ? AND status = ? AND deleted_at IS NULL", gameID, models.VerificationConfirmed).
+		Group("verified_user_id")
+
+	phrases := database.DB.Table("phrases").
+		Select("submitted_by as user_id, COUNT(*) as total").
+		Where("game_id = ? AND deleted_at IS NULL", gameID).
+		Group("submitted_by")
+
+	statistics := make([]struct {
+		UserID                uint       `json:"user_id"`
+		Username              string     `json:"username"`
+		Role                  int        `json:"role"`
+		IsEliminated          bool       `json:"is_eliminated"`
+		EliminatedAt          *time.Time `json:"eliminated_at"`
+		EliminationReason     string     `json:"elimination_reason"`
+		VerificationsReceived int64      `json:"verifications_received"`
+		PhrasesSubmitted      int64      `json:"phrases_submitted"`
+	}, 0)
+
+	if err := database.DB.Table("game_members").
+		Select("game_members.user_id, users.username, game_members.role, game_members.is_eliminated, "+
+			"game_members.eliminated_at, game_members.elimination_reason, "+
+			"COALESCE(received.total, 0) as verifications_received, COALESCE(phrases.total, 0) as phrases_submitted").
+		Joins("JOIN users ON users.id = game_members.user_id AND users.deleted_at IS NULL").
+		Joins("LEFT JOIN (?) AS received ON received.user_id = game_members.user_id", received).
+		Joins("LEFT JOIN (?) AS phrases ON phrases.user_id = game_members.user_id", phrases).
+		Where("game_members.game_id = ?", gameID).
+		Order("game_members.user_id asc").
+		Find(&statistics).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
 		return
 	}
 
-	statistics := make([]map[string]interface{}, 0)
-	for _, user := range users {
-		var verificationsCount int64
-		database.DB.Model(&models.Verification{}).Where("verified_user_id = ?", user.ID).Count(&verificationsCount)
-
-		var phrasesCount int64
-		database.DB.Model(&models.Phrase{}).Where("submitted_by = ?", user.ID).Count(&phrasesCount)
-
-		stats := map[string]interface{}{
-			"user_id":                user.ID,
-			"username":               user.Username,
-			"privilege":              user.Privilege,
-			"is_eliminated":          user.IsEliminated,
-			"verifications_received": verificationsCount,
-			"phrases_submitted":      phrasesCount,
-		}
-
-		statistics = append(statistics, stats)
-	}
-
 	c.JSON(http.StatusOK, gin.H{"statistics": statistics})
 }
 
 func ManualReset(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
 	var input struct {
 		OpenTime int64 `json:"open_time" binding:"required"`
 	}
@@ -49,26 +64,43 @@ func ManualReset(c *gin.Context) {
 		return
 	}
 
-	targetTime := time.Unix(input.OpenTime, 0)
+	targetTime := time.Unix(input.OpenTime, 0).UTC()
 
 	// Validate that the open time is in the future
-	if targetTime.Before(time.Now()) {
+	if targetTime.Before(utils.Now()) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Open time must be in the future"})
 		return
 	}
 
-	// Create new submission window
-	window := models.SubmissionWindow{
-		OpenTime: targetTime,
+	settings := utils.GetGameSchedule(gameID)
+	schedule, err := utils.CompileSchedule(&settings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid game schedule"})
+		return
 	}
+	day := schedule.LocalDay(targetTime)
+
+	// Create new submission window
+	window := utils.PlanWindow(gameID, targetTime)
 
 	// Start a transaction
 	tx := database.DB.Begin()
 
-	// Clean up any existing phrases and verifications for windows that haven't opened yet
-	if err := tx.Unscoped().Where("open_time > ?", time.Now()).Delete(&models.SubmissionWindow{}).Error; err != nil {
+	// Replace only the unopened windows on the same local day, later days
+	// keep theirs. Lasting changes belong in schedule overrides. Replaced
+	// windows are soft deleted so their commitments can still be audited.
+	if err := tx.
+		Where("game_id = ? AND open_time > ?", gameID, utils.Now()).
+		Where("open_time >= ? AND open_time < ?", day.UTC(), day.AddDate(0, 0, 1).UTC()).
+		Delete(&models.SubmissionWindow{}).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up future windows"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up windows on that day"})
+		return
+	}
+
+	if err := utils.FitWindow(tx, &window); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Another window is still running at that time"})
 		return
 	}
 
@@ -85,6 +117,8 @@ func ManualReset(c *gin.Context) {
 		return
 	}
 
+	utils.TriggerUpdate(gameID, utils.UpdateWindow)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":   "Manual reset scheduled",
 		"window_id": window.ID,
@@ -132,31 +166,44 @@ func DemoteUser(c *gin.Context) {
 		return
 	}
 
+	// Sign them out so no client keeps acting on the old privilege
+	if err := utils.RevokeUserSessions(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke user's sessions"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "User demoted successfully"})
 }
 
 // New endpoint to view scheduled windows
 func GetScheduledWindows(c *gin.Context) {
 	var windows []models.SubmissionWindow
-	if err := database.DB.Where("open_time > ?", time.Now()).Order("open_time asc").Find(&windows).Error; err != nil {
+	if err := database.DB.Where("game_id = ? AND open_time > ?", c.GetUint("gameID"), utils.Now()).Order("open_time asc").Find(&windows).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scheduled windows"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"windows": windows})
+	// Hidden windows show their commitment but not their open time
+	response := make([]gin.H, len(windows))
+	for i := range windows {
+		response[i] = windowResponse(&windows[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"windows": response})
 }
 
 // New endpoint to cancel a scheduled window
 func CancelScheduledWindow(c *gin.Context) {
+	gameID := c.GetUint("gameID")
 	windowID := c.Param("id")
 
 	var window models.SubmissionWindow
-	if err := database.DB.First(&window, windowID).Error; err != nil {
+	if err := database.DB.Where("game_id = ?", gameID).First(&window, windowID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Window not found"})
 		return
 	}
 
-	if window.OpenTime.Before(time.Now()) {
+	if window.OpenTime.Before(utils.Now()) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot cancel window that has already opened"})
 		return
 	}
@@ -166,14 +213,17 @@ func CancelScheduledWindow(c *gin.Context) {
 		return
 	}
 
+	utils.TriggerUpdate(gameID, utils.UpdateWindow)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Window cancelled successfully"})
 }
 
 func EditPhrase(c *gin.Context) {
 	userID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
 
-	var window models.SubmissionWindow
-	if err := database.DB.Order("open_time desc").First(&window).Error; err != nil {
+	window, err := utils.GetCurrentWindow(gameID)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No active submission window"})
 		return
 	}
@@ -187,41 +237,65 @@ func EditPhrase(c *gin.Context) {
 		return
 	}
 
+	// Verifications made against the phrase would no longer match it
+	if window.Phase != models.WindowLocked {
+		respondWrongPhase(c, window, "The phrase can only be changed before verification opens")
+		return
+	}
+
 	var phrase models.Phrase
 	if err := database.DB.Where("submission_window = ?", window.ID).First(&phrase).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No phrase found for current window"})
 		return
 	}
 
-	phrase.Content = input.Content
+	content, err := utils.ModeratePhrase(gameID, input.Content, window.ID)
+	if err != nil {
+		respondPhraseRejected(c, err)
+		return
+	}
+
+	phrase.Content = content
 	phrase.SubmittedBy = userID
+	phrase.BankPhraseID = nil
 
 	if err := database.DB.Save(&phrase).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to edit phrase"})
 		return
 	}
 
+	utils.TriggerUpdate(gameID, utils.UpdatePhrase)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Phrase edited successfully"})
 }
 
 func UnsubmitPhrase(c *gin.Context) {
-	var window models.SubmissionWindow
-	if err := database.DB.Order("open_time desc").First(&window).Error; err != nil {
+	gameID := c.GetUint("gameID")
+
+	window, err := utils.GetCurrentWindow(gameID)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No active submission window"})
 		return
 	}
 
-	result := database.DB.Unscoped().Where("submission_window = ?", window.ID).Delete(&models.Phrase{})
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubmit phrase"})
+	if window.Phase == models.WindowOpen {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No phrase found for current window"})
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No phrase found for current window"})
+	// Reopens the window so a new phrase can be claimed
+	err = utils.ReopenWindow(window)
+	if errors.Is(err, utils.ErrWrongPhase) {
+		respondWrongPhase(c, window, "The phrase can only be changed before verification opens")
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubmit phrase"})
+		return
+	}
+
+	utils.TriggerUpdate(gameID, utils.UpdatePhrase)
+	utils.TriggerUpdate(gameID, utils.UpdateWindow)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Phrase unsubmitted successfully"})
 }
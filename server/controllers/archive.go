@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPhraseArchive pages through every phrase the game has used, with
+// optional search
+func GetPhraseArchive(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Page size must be between 1 and 100"})
+		return
+	}
+
+	var submittedBy uint64
+	if value := c.Query("user_id"); value != "" {
+		if submittedBy, err = strconv.ParseUint(value, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+	}
+
+	phrases, total, err := utils.GetPhraseArchive(utils.PhraseArchiveQuery{
+		GameID:      c.GetUint("gameID"),
+		Search:      c.Query("q"),
+		SubmittedBy: uint(submittedBy),
+		Page:        page,
+		PageSize:    pageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch phrases"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"phrases":   phrases,
+	})
+}
@@ -12,8 +12,9 @@ import (
 
 func SignUp(c *gin.Context) {
 	var input struct {
-		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required,min=8"`
+		Username   string `json:"username" binding:"required"`
+		Password   string `json:"password" binding:"required,min=8"`
+		InviteCode string `json:"invite_code"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -21,25 +22,40 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+	if err := utils.ValidateUsername(input.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	user := models.User{
-		Username:     input.Username,
-		PasswordHash: string(hashedPassword),
+	hashedPassword, err := utils.HashPassword(input.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if _, err := utils.RegisterUser(input.Username, hashedPassword, input.InviteCode); err != nil {
+		switch err {
+		case utils.ErrRegistrationClosed, utils.ErrInviteRequired:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case utils.ErrInviteInvalid:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case utils.ErrUsernameTaken:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "User created successfully"})
 }
 
+// RegistrationInfo tells clients whether to offer signup and ask for an
+// invite code
+func RegistrationInfo(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"mode": utils.GetRegistrationMode()})
+}
+
 func Login(c *gin.Context) {
 	var input struct {
 		Username string `json:"username" binding:"required"`
@@ -53,32 +69,43 @@ func Login(c *gin.Context) {
 
 	var user models.User
 	if err := database.DB.Where("username = ?", input.Username).First(&user).Error; err != nil {
+		utils.RecordFailedLogin(input.Username, nil, c.ClientIP(), c.Request.UserAgent(), models.LoginUnknownUser)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+		utils.RecordFailedLogin(input.Username, &user.ID, c.ClientIP(), c.Request.UserAgent(), models.LoginBadPassword)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Privilege)
+	tokens, err := utils.CreateSession(&user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Login successful", "token": token})
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Login successful",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"session_id":    tokens.SessionID,
+	})
 }
 
 func Privilege(c *gin.Context) {
 	var user models.User
-	userID := c.Param("id")
-
-	if err := database.DB.First(&user, userID).Error; err != nil {
+	if err := database.DB.First(&user, c.GetUint("userID")).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"privilege": user.Privilege})
 }
+
+// JWKS publishes the public keys other services can verify our tokens with
+func JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"keys": utils.JWKS()})
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Longest single jump, matching how far back the scheduler replays
+const maxClockAdvance = 30 * 24 * time.Hour
+
+func clockResponse(sim *utils.SimClock) gin.H {
+	now := sim.Now().UTC()
+	return gin.H{
+		"now":            now,
+		"real_now":       time.Now().UTC(),
+		"offset_seconds": int64(now.Sub(time.Now()).Seconds()),
+		"frozen":         sim.Frozen(),
+	}
+}
+
+// GetClock reports game time against real time. The clock routes are only
+// registered when the simulated clock is on, so SimulatedClock is never nil
+// in them.
+func GetClock(c *gin.Context) {
+	c.JSON(http.StatusOK, clockResponse(utils.SimulatedClock()))
+}
+
+func FreezeClock(c *gin.Context) {
+	sim := utils.SimulatedClock()
+	sim.Freeze()
+	c.JSON(http.StatusOK, clockResponse(sim))
+}
+
+func ResumeClock(c *gin.Context) {
+	sim := utils.SimulatedClock()
+	sim.Resume()
+	c.JSON(http.StatusOK, clockResponse(sim))
+}
+
+// AdvanceClock moves game time forward. Scheduled jobs run for the skipped
+// time before it returns, so windows open, close and get scored on the way.
+func AdvanceClock(c *gin.Context) {
+	var input struct {
+		Duration string `json:"duration" binding:"required"` // e.g. 90m or 24h
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	d, err := time.ParseDuration(input.Duration)
+	if err != nil || d <= 0 || d > maxClockAdvance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Duration must be positive and at most 720h"})
+		return
+	}
+
+	sim := utils.SimulatedClock()
+	utils.AdvanceClock(sim, d)
+	c.JSON(http.StatusOK, clockResponse(sim))
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errAlreadyDisputed = errors.New("verification is already disputed or rejected")
+	errDisputeResolved = errors.New("dispute has already been resolved")
+)
+
+func DisputeVerification(c *gin.Context) {
+	userID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
+
+	var input struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var verification models.Verification
+	if err := database.DB.Where("game_id = ?", gameID).First(&verification, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Verification not found"})
+		return
+	}
+
+	if verification.VerifierID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot dispute your own verification"})
+		return
+	}
+
+	switch verification.Status {
+	case models.VerificationDisputed:
+		c.JSON(http.StatusConflict, gin.H{"error": "Verification is already disputed"})
+		return
+	case models.VerificationRejected:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification has already been rejected"})
+		return
+	}
+
+	// Disputes hold up scoring, so they're taken until the window is scored
+	window, err := utils.GetWindow(verification.SubmissionWindow)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Window not found"})
+		return
+	}
+	if window.Phase != models.WindowVerifying && window.Phase != models.WindowClosed {
+		respondWrongPhase(c, window, "Verifications in this window can't be disputed")
+		return
+	}
+
+	dispute := models.Dispute{
+		GameID:         gameID,
+		VerificationID: verification.ID,
+		RaisedBy:       userID,
+		Reason:         input.Reason,
+		Status:         models.DisputeOpen,
+		CreatedAt:      utils.Now(),
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Guarded so only one of two concurrent disputes goes through
+		result := tx.Model(&models.Verification{}).
+			Where("id = ? AND status NOT IN ?", verification.ID, []string{models.VerificationDisputed, models.VerificationRejected}).
+			Update("status", models.VerificationDisputed)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAlreadyDisputed
+		}
+
+		return tx.Create(&dispute).Error
+	})
+	if errors.Is(err, errAlreadyDisputed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Verification is already disputed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dispute verification"})
+		return
+	}
+
+	utils.TriggerUpdate(gameID, utils.UpdateVerification)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Verification disputed", "dispute_id": dispute.ID})
+}
+
+func GetDisputes(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+	status := c.DefaultQuery("status", models.DisputeOpen)
+
+	var disputes []struct {
+		DisputeID          uint       `json:"dispute_id"`
+		VerificationID     uint       `json:"verification_id"`
+		SubmissionWindow   uint       `json:"submission_window"`
+		VerifierID         uint       `json:"verifier_id"`
+		VerifierName       string     `json:"verifier_name"`
+		VerifiedID         uint       `json:"verified_id"`
+		VerifiedName       string     `json:"verified_name"`
+		RaisedBy           uint       `json:"raised_by"`
+		RaisedByName       string     `json:"raised_by_name"`
+		Reason             string     `json:"reason"`
+		Status             string     `json:"status"`
+		VerificationStatus string     `json:"verification_status"`
+		ResolvedBy         *uint      `json:"resolved_by"`
+		ResolvedAt         *time.Time `json:"resolved_at"`
+		Resolution         string     `json:"resolution"`
+		CreatedAt          time.Time  `json:"created_at"`
+	}
+
+	query := database.DB.Table("disputes").
+		Select("disputes.id as dispute_id, disputes.verification_id, verifications.submission_window, "+
+			"verifications.verifier_id, u1.username as verifier_name, verifications.verified_user_id as verified_id, "+
+			"u2.username as verified_name, disputes.raised_by, u3.username as raised_by_name, disputes.reason, "+
+			"disputes.status, verifications.status as verification_status, disputes.resolved_by, "+
+			"disputes.resolved_at, disputes.resolution, disputes.created_at").
+		Joins("JOIN verifications ON verifications.id = disputes.verification_id").
+		Joins("JOIN users u1 ON u1.id = verifications.verifier_id").
+		Joins("JOIN users u2 ON u2.id = verifications.verified_user_id").
+		Joins("JOIN users u3 ON u3.id = disputes.raised_by").
+		Where("disputes.game_id = ? AND disputes.deleted_at IS NULL", gameID).
+		Order("disputes.created_at asc")
+
+	if status != "all" {
+		query = query.Where("disputes.status = ?", status)
+	}
+
+	if err := query.Find(&disputes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch disputes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, disputes)
+}
+
+func ResolveDispute(c *gin.Context) {
+	adminID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
+
+	var input struct {
+		Decision   string `json:"decision" binding:"required,oneof=uphold dismiss"`
+		Resolution string `json:"resolution" binding:"max=500"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var dispute models.Dispute
+	if err := database.DB.Where("game_id = ?", gameID).First(&dispute, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dispute not found"})
+		return
+	}
+
+	if dispute.Status != models.DisputeOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Dispute has already been resolved"})
+		return
+	}
+
+	var verification models.Verification
+	if err := database.DB.First(&verification, dispute.VerificationID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Verification not found"})
+		return
+	}
+
+	status := models.DisputeDismissed
+	if input.Decision == "uphold" {
+		status = models.DisputeUpheld
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Guarded so two admins can't both resolve it
+		result := tx.Model(&models.Dispute{}).
+			Where("id = ? AND status = ?", dispute.ID, models.DisputeOpen).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"resolved_by": adminID,
+				"resolved_at": utils.Now(),
+				"resolution":  input.Resolution,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errDisputeResolved
+		}
+
+		if status == models.DisputeUpheld {
+			return tx.Model(&verification).Update("status", models.VerificationRejected).Error
+		}
+
+		// A dismissed dispute returns the verification to wherever its
+		// confirmations put it
+		return utils.SettleVerification(tx, &verification)
+	})
+	if errors.Is(err, errDisputeResolved) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Dispute has already been resolved"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve dispute"})
+		return
+	}
+
+	utils.TriggerUpdate(gameID, utils.UpdateVerification)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dispute resolved", "status": status})
+}
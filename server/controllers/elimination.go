@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type eliminationRecord struct {
+	EliminationID     uint       `json:"elimination_id"`
+	GameID            uint       `json:"game_id"`
+	UserID            uint       `json:"user_id"`
+	Username          string     `json:"username"`
+	SubmissionWindow  uint       `json:"submission_window"`
+	WindowOpenTime    time.Time  `json:"window_open_time"`
+	Reason            string     `json:"reason"`
+	EliminatedAt      time.Time  `json:"eliminated_at"`
+	ResurrectedBy     *uint      `json:"resurrected_by"`
+	ResurrectedByName *string    `json:"resurrected_by_name"`
+	ResurrectedAt     *time.Time `json:"resurrected_at"`
+	ResurrectNote     string     `json:"resurrect_note"`
+}
+
+func eliminationRecordsQuery() *gorm.DB {
+	return database.DB.Table("eliminations").
+		Select("eliminations.id as elimination_id, eliminations.game_id, eliminations.user_id, u1.username, " +
+			"eliminations.submission_window, submission_windows.open_time as window_open_time, " +
+			"eliminations.reason, eliminations.created_at as eliminated_at, eliminations.resurrected_by, " +
+			"u2.username as resurrected_by_name, eliminations.resurrected_at, eliminations.resurrect_note").
+		Joins("JOIN users u1 ON eliminations.user_id = u1.id").
+		Joins("LEFT JOIN users u2 ON eliminations.resurrected_by = u2.id").
+		Joins("LEFT JOIN submission_windows ON eliminations.submission_window = submission_windows.id").
+		Where("eliminations.deleted_at IS NULL").
+		Order("eliminations.created_at desc")
+}
+
+func GetMyEliminations(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var records []eliminationRecord
+	if err := eliminationRecordsQuery().Where("eliminations.user_id = ?", userID).Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch eliminations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+func GetEliminations(c *gin.Context) {
+	query := eliminationRecordsQuery().Where("eliminations.game_id = ?", c.GetUint("gameID"))
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("eliminations.user_id = ?", userID)
+	}
+
+	var records []eliminationRecord
+	if err := query.Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch eliminations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+func ResurrectUser(c *gin.Context) {
+	adminID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
+	userID := c.Param("id")
+
+	var input struct {
+		Note string `json:"note"`
+	}
+
+	// The note is optional, so an empty body is fine
+	if c.Request.Body != http.NoBody {
+		if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var member models.GameMember
+	if err := database.DB.Where("game_id = ? AND user_id = ?", gameID, userID).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !member.IsEliminated {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User is not eliminated"})
+		return
+	}
+
+	now := utils.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&member).Updates(map[string]interface{}{
+			"is_eliminated":      false,
+			"eliminated_at":      nil,
+			"elimination_reason": "",
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Elimination{}).
+			Where("game_id = ? AND user_id = ? AND resurrected_at IS NULL", gameID, member.UserID).
+			Updates(map[string]interface{}{
+				"resurrected_by": adminID,
+				"resurrected_at": now,
+				"resurrect_note": input.Note,
+			}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resurrect user"})
+		return
+	}
+
+	utils.TriggerUpdate(gameID, utils.UpdateElimination)
+
+	c.JSON(http.StatusOK, gin.H{"message": "User resurrected successfully"})
+}
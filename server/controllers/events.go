@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"io"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Events streams a game's state changes to the client as server-sent events
+func Events(c *gin.Context) {
+	ch := utils.Events.Subscribe(c.GetUint("gameID"))
+	defer utils.Events.Unsubscribe(ch)
+
+	// Keep proxies from closing idle connections
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return false
+			}
+			c.SSEvent("message", event)
+			return true
+		case <-heartbeat.C:
+			// Drop streams whose session was revoked after they connected
+			if _, err := utils.AuthenticateSession(c.GetUint("userID"), c.GetUint("sessionID")); err != nil {
+				return false
+			}
+			c.SSEvent("heartbeat", time.Now().Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const maxEvidenceFiles = 5
+
+type attachmentInfo struct {
+	ID       uint   `json:"id"`
+	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+}
+
+// saveEvidence writes uploaded files to disk. If any file is rejected, the
+// ones already written are removed again.
+func saveEvidence(files []*multipart.FileHeader, uploadedBy uint) ([]models.VerificationAttachment, error) {
+	attachments := make([]models.VerificationAttachment, 0, len(files))
+	for _, file := range files {
+		storedName, mimeType, err := utils.SaveEvidence(file)
+		if err != nil {
+			removeEvidence(attachments)
+			return nil, fmt.Errorf("%s: %w", file.Filename, err)
+		}
+		attachments = append(attachments, models.VerificationAttachment{
+			UploadedBy: uploadedBy,
+			FileName:   file.Filename,
+			StoredName: storedName,
+			MimeType:   mimeType,
+			Size:       file.Size,
+			CreatedAt:  utils.Now(),
+		})
+	}
+	return attachments, nil
+}
+
+func removeEvidence(attachments []models.VerificationAttachment) {
+	for _, attachment := range attachments {
+		utils.RemoveEvidence(attachment.StoredName)
+	}
+}
+
+func attachmentsFor(verificationIDs []uint) (map[uint][]attachmentInfo, error) {
+	byVerification := make(map[uint][]attachmentInfo)
+	if len(verificationIDs) == 0 {
+		return byVerification, nil
+	}
+
+	var attachments []models.VerificationAttachment
+	if err := database.DB.Where("verification_id IN ?", verificationIDs).
+		Order("id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+
+	for _, a := range attachments {
+		byVerification[a.VerificationID] = append(byVerification[a.VerificationID], attachmentInfo{
+			ID:       a.ID,
+			FileName: a.FileName,
+			MimeType: a.MimeType,
+			Size:     a.Size,
+		})
+	}
+	return byVerification, nil
+}
+
+func AddVerificationAttachments(c *gin.Context) {
+	userID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
+
+	var verification models.Verification
+	if err := database.DB.Where("game_id = ?", gameID).First(&verification, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Verification not found"})
+		return
+	}
+
+	if verification.VerifierID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the verifier can attach evidence"})
+		return
+	}
+
+	if verification.Status == models.VerificationRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification has been rejected"})
+		return
+	}
+
+	window, err := utils.GetWindow(verification.SubmissionWindow)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Window not found"})
+		return
+	}
+	if window.Phase != models.WindowVerifying {
+		respondWrongPhase(c, window, "Evidence can only be added while verification is open")
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["evidence"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No evidence files provided"})
+		return
+	}
+	files := form.File["evidence"]
+
+	var existing int64
+	database.DB.Model(&models.VerificationAttachment{}).Where("verification_id = ?", verification.ID).Count(&existing)
+	if existing+int64(len(files)) > maxEvidenceFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d evidence files can be attached", maxEvidenceFiles)})
+		return
+	}
+
+	attachments, err := saveEvidence(files, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range attachments {
+		attachments[i].VerificationID = verification.ID
+	}
+
+	if err := database.DB.Create(&attachments).Error; err != nil {
+		removeEvidence(attachments)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachments"})
+		return
+	}
+
+	utils.TriggerUpdate(gameID, utils.UpdateVerification)
+
+	added := make([]attachmentInfo, len(attachments))
+	for i, a := range attachments {
+		added[i] = attachmentInfo{ID: a.ID, FileName: a.FileName, MimeType: a.MimeType, Size: a.Size}
+	}
+	c.JSON(http.StatusCreated, added)
+}
+
+func GetVerificationAttachment(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var attachment models.VerificationAttachment
+	err := database.DB.Joins("JOIN verifications ON verifications.id = verification_attachments.verification_id").
+		Where("verifications.game_id = ? AND verifications.id = ?", gameID, c.Param("id")).
+		First(&attachment, c.Param("attachmentID")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+
+	c.Header("Content-Type", attachment.MimeType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.File(utils.EvidencePath(attachment.StoredName))
+}
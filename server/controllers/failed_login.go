@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetFailedLogins lists failed login attempts, newest first, optionally
+// filtered by username or IP address
+func GetFailedLogins(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Page size must be between 1 and 100"})
+		return
+	}
+
+	query := database.DB.Model(&models.FailedLogin{})
+	if username := c.Query("username"); username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if ip := c.Query("ip"); ip != "" {
+		query = query.Where("ip_address = ?", ip)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch failed logins"})
+		return
+	}
+
+	var attempts []struct {
+		ID        uint      `json:"id"`
+		Username  string    `json:"username"`
+		UserID    *uint     `json:"user_id"`
+		IPAddress string    `json:"ip_address"`
+		UserAgent string    `json:"user_agent"`
+		Reason    string    `json:"reason"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	if err := query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&attempts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch failed logins"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"attempts":  attempts,
+	})
+}
+
+// GetFailedLoginSummary shows the IP addresses and usernames with the most
+// failed logins recently, which is where brute force attempts stand out
+func GetFailedLoginSummary(c *gin.Context) {
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if err != nil || hours < 1 || hours > 24*90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hours must be between 1 and 2160"})
+		return
+	}
+	since := time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
+
+	type bucket struct {
+		Key           string    `json:"key"`
+		Failures      int64     `json:"failures"`
+		DistinctKeys  int64     `json:"distinct_keys"` // Usernames tried from an IP, or IPs that tried a username
+		LastAttempt   string    `json:"-"`
+		LastAttemptAt time.Time `json:"last_attempt" gorm:"-"`
+	}
+
+	var byIP, byUsername []bucket
+	if err := database.DB.Model(&models.FailedLogin{}).
+		Select("ip_address as key, COUNT(*) as failures, COUNT(DISTINCT username) as distinct_keys, MAX(created_at) as last_attempt").
+		Where("created_at >= ?", since).
+		Group("ip_address").Order("failures desc").Limit(20).
+		Scan(&byIP).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize failed logins"})
+		return
+	}
+	if err := database.DB.Model(&models.FailedLogin{}).
+		Select("username as key, COUNT(*) as failures, COUNT(DISTINCT ip_address) as distinct_keys, MAX(created_at) as last_attempt").
+		Where("created_at >= ?", since).
+		Group("username").Order("failures desc").Limit(20).
+		Scan(&byUsername).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize failed logins"})
+		return
+	}
+
+	// SQLite hands aggregated timestamps back as text
+	for _, buckets := range [][]bucket{byIP, byUsername} {
+		for i := range buckets {
+			buckets[i].LastAttemptAt, _ = time.Parse("2006-01-02 15:04:05.999999999-07:00", buckets[i].LastAttempt)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":       since,
+		"by_ip":       byIP,
+		"by_username": byUsername,
+	})
+}
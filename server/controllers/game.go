@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type gameSummary struct {
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	Role         int       `json:"role"`
+	IsEliminated bool      `json:"is_eliminated"`
+	JoinedAt     time.Time `json:"joined_at"`
+}
+
+type gameMemberInfo struct {
+	UserID            uint       `json:"user_id"`
+	Username          string     `json:"username"`
+	Role              int        `json:"role"`
+	IsEliminated      bool       `json:"is_eliminated"`
+	EliminatedAt      *time.Time `json:"eliminated_at"`
+	EliminationReason string     `json:"elimination_reason"`
+	JoinedAt          time.Time  `json:"joined_at"`
+}
+
+func CreateGame(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var input struct {
+		Name string `json:"name" binding:"required,max=64"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	game, err := utils.CreateGame(strings.TrimSpace(input.Name), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create game"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Game created successfully",
+		"game_id":     game.ID,
+		"invite_code": game.InviteCode,
+	})
+}
+
+func GetMyGames(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var games []gameSummary
+	if err := database.DB.Table("games").
+		Select("games.id, games.name, game_members.role, game_members.is_eliminated, game_members.created_at as joined_at").
+		Joins("JOIN game_members ON game_members.game_id = games.id").
+		Where("game_members.user_id = ? AND games.deleted_at IS NULL", userID).
+		Order("games.id asc").
+		Find(&games).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch games"})
+		return
+	}
+
+	c.JSON(http.StatusOK, games)
+}
+
+func JoinGame(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var input struct {
+		InviteCode string `json:"invite_code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var game models.Game
+	if err := database.DB.Where("invite_code = ?", utils.NormalizeCode(input.InviteCode)).First(&game).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid invite code"})
+		return
+	}
+
+	if _, err := utils.GetMembership(game.ID, userID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Already a member of this game"})
+		return
+	}
+
+	member := models.GameMember{
+		GameID:    game.ID,
+		UserID:    userID,
+		Role:      models.RolePlayer,
+		CreatedAt: utils.Now(),
+	}
+
+	if err := database.DB.Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join game"})
+		return
+	}
+
+	utils.TriggerUpdate(game.ID, utils.UpdateMembers)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Joined game successfully", "game_id": game.ID})
+}
+
+func GetGame(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var game models.Game
+	if err := database.DB.First(&game, gameID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		return
+	}
+
+	var members []gameMemberInfo
+	if err := database.DB.Table("game_members").
+		Select("game_members.user_id, users.username, game_members.role, game_members.is_eliminated, "+
+			"game_members.eliminated_at, game_members.elimination_reason, game_members.created_at as joined_at").
+		Joins("JOIN users ON users.id = game_members.user_id AND users.deleted_at IS NULL").
+		Where("game_members.game_id = ?", gameID).
+		Order("game_members.created_at asc").
+		Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch members"})
+		return
+	}
+
+	response := gin.H{
+		"id":                     game.ID,
+		"name":                   game.Name,
+		"elimination_rules":      utils.GameEliminationRules(&game),
+		"required_confirmations": game.RequiredConfirmations,
+		"phrase_deadline":        game.PhraseDeadline,
+		"phrase_lock":            game.PhraseLock,
+		"hide_open_times":        game.HideOpenTimes,
+		"role":                   c.GetInt("gameRole"),
+		"members":                members,
+	}
+
+	// Only admins may hand out the invite code
+	if c.GetInt("gameRole") >= models.RoleAdmin {
+		response["invite_code"] = game.InviteCode
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func LeaveGame(c *gin.Context) {
+	userID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
+
+	if c.GetInt("gameRole") == models.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "The owner cannot leave the game"})
+		return
+	}
+
+	member, err := utils.GetMembership(gameID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not a member of this game"})
+		return
+	}
+
+	// Rejoining would bring back a fresh membership, clearing the elimination
+	// and exempting the member from the window under way
+	if member.IsEliminated {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Eliminated members cannot leave the game"})
+		return
+	}
+	if _, err := utils.GetActiveSeason(gameID); err == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Members cannot leave while a season is running"})
+		return
+	}
+
+	if err := database.DB.Delete(member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave game"})
+		return
+	}
+
+	utils.TriggerUpdate(gameID, utils.UpdateMembers)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left game successfully"})
+}
+
+func UpdateGame(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var input struct {
+		Name                  *string   `json:"name" binding:"omitempty,max=64"`
+		EliminationRules      *[]string `json:"elimination_rules"`
+		RequiredConfirmations *int      `json:"required_confirmations" binding:"omitempty,min=1,max=10"`
+		PhraseDeadline        *int      `json:"phrase_deadline" binding:"omitempty,min=0,max=1440"`
+		PhraseLock            *int      `json:"phrase_lock" binding:"omitempty,min=0,max=120"`
+		HideOpenTimes         *bool     `json:"hide_open_times"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+			return
+		}
+		updates["name"] = name
+	}
+	if input.EliminationRules != nil {
+		if err := utils.ValidateEliminationRules(*input.EliminationRules); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["elimination_rules"] = strings.Join(*input.EliminationRules, ",")
+	}
+	if input.RequiredConfirmations != nil {
+		updates["required_confirmations"] = *input.RequiredConfirmations
+	}
+	if input.PhraseDeadline != nil {
+		updates["phrase_deadline"] = *input.PhraseDeadline
+	}
+	if input.PhraseLock != nil {
+		updates["phrase_lock"] = *input.PhraseLock
+	}
+	if input.HideOpenTimes != nil {
+		updates["hide_open_times"] = *input.HideOpenTimes
+	}
+
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	if err := database.DB.Model(&models.Game{ID: gameID}).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update game"})
+		return
+	}
+
+	// Hiding only covers windows planned from now on, and the upcoming
+	// window's time may already have been seen, so draw it again
+	if input.HideOpenTimes != nil && *input.HideOpenTimes {
+		var game models.Game
+		if err := database.DB.First(&game, gameID).Error; err != nil || utils.RescheduleGame(&game) != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule upcoming window"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Game updated successfully"})
+}
+
+func RegenerateInviteCode(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	code, err := utils.GenerateInviteCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate invite code"})
+		return
+	}
+
+	if err := database.DB.Model(&models.Game{ID: gameID}).Update("invite_code", code).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invite code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invite code regenerated", "invite_code": code})
+}
+
+func SetMemberRole(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+	userID := c.Param("id")
+
+	var input struct {
+		Role *int `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Ownership isn't transferable through this endpoint
+	if *input.Role != models.RolePlayer && *input.Role != models.RoleAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be 0 (player) or 1 (admin)"})
+		return
+	}
+
+	var member models.GameMember
+	if err := database.DB.Where("game_id = ? AND user_id = ?", gameID, userID).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if member.Role == models.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot change the owner's role"})
+		return
+	}
+
+	if err := database.DB.Model(&member).Update("role", *input.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	utils.TriggerUpdate(gameID, utils.UpdateMembers)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully"})
+}
+
+func GetAllGames(c *gin.Context) {
+	var games []struct {
+		ID          uint      `json:"id"`
+		Name        string    `json:"name"`
+		CreatedBy   uint      `json:"created_by"`
+		MemberCount int64     `json:"member_count"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	if err := database.DB.Table("games").
+		Select("games.id, games.name, games.created_by, COUNT(game_members.id) as member_count, games.created_at").
+		Joins("LEFT JOIN game_members ON game_members.game_id = games.id").
+		Where("games.deleted_at IS NULL").
+		Group("games.id").
+		Order("games.id asc").
+		Find(&games).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch games"})
+		return
+	}
+
+	c.JSON(http.StatusOK, games)
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func GetMyHistory(c *gin.Context) {
+	member, err := utils.GetMembership(c.GetUint("gameID"), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not a member of this game"})
+		return
+	}
+
+	history, err := utils.GetUserHistory(member)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+		return
+	}
+
+	stats, err := utils.GetUserStats(member)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats, "history": history})
+}
+
+func GetUserStats(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var member models.GameMember
+	if err := database.DB.Where("game_id = ? AND user_id = ?", gameID, c.Param("id")).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	stats, err := utils.GetUserStats(&member)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type signupInviteInfo struct {
+	ID        uint       `json:"id"`
+	Code      string     `json:"code"`
+	CreatedBy uint       `json:"created_by"`
+	Note      string     `json:"note"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Privilege int        `json:"privilege"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func newSignupInviteInfo(invite *models.SignupInvite) signupInviteInfo {
+	return signupInviteInfo{
+		ID:        invite.ID,
+		Code:      invite.Code,
+		CreatedBy: invite.CreatedBy,
+		Note:      invite.Note,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		ExpiresAt: invite.ExpiresAt,
+		Privilege: invite.Privilege,
+		CreatedAt: invite.CreatedAt,
+	}
+}
+
+func CreateSignupInvite(c *gin.Context) {
+	var input struct {
+		MaxUses        *int   `json:"max_uses" binding:"omitempty,min=0,max=1000"`
+		ExpiresInHours int    `json:"expires_in_hours" binding:"min=0,max=8760"`
+		Privilege      int    `json:"privilege" binding:"min=0,max=2"`
+		Note           string `json:"note" binding:"max=200"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Admins can only hand out privileges below their own
+	if input.Privilege >= c.GetInt("privilege") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot grant a privilege equal to or above your own"})
+		return
+	}
+
+	maxUses := 1
+	if input.MaxUses != nil {
+		maxUses = *input.MaxUses
+	}
+
+	var expiresAt *time.Time
+	if input.ExpiresInHours > 0 {
+		t := time.Now().UTC().Add(time.Duration(input.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+	}
+
+	invite, err := utils.CreateSignupInvite(c.GetUint("userID"), maxUses, expiresAt, input.Privilege, strings.TrimSpace(input.Note))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newSignupInviteInfo(invite))
+}
+
+func GetSignupInvites(c *gin.Context) {
+	var invites []models.SignupInvite
+	if err := database.DB.Order("created_at desc").Find(&invites).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invites"})
+		return
+	}
+
+	response := make([]signupInviteInfo, len(invites))
+	for i := range invites {
+		response[i] = newSignupInviteInfo(&invites[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func RevokeSignupInvite(c *gin.Context) {
+	result := database.DB.Delete(&models.SignupInvite{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invite"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invite revoked"})
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func GetLeaderboard(c *gin.Context) {
+	mode := c.DefaultQuery("mode", utils.LeaderboardReceived)
+	rangeName := c.DefaultQuery("range", utils.RangeAll)
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Page size must be between 1 and 100"})
+		return
+	}
+
+	entries, total, err := utils.GetLeaderboard(c.GetUint("gameID"), mode, rangeName, page, pageSize)
+	if errors.Is(err, utils.ErrNoSeason) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This game has no seasons"})
+		return
+	}
+	if errors.Is(err, utils.ErrUnknownLeaderboard) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute leaderboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"mode":      mode,
+		"range":     rangeName,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"entries":   entries,
+	})
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const maxPreferencesSize = 4096
+
+// gameStatus is the caller's standing in one game for the current window
+type gameStatus struct {
+	GameID          uint            `json:"game_id"`
+	Name            string          `json:"name"`
+	Role            int             `json:"role"`
+	IsEliminated    bool            `json:"is_eliminated"`
+	EliminatedAt    *time.Time      `json:"eliminated_at"`
+	WindowID        *uint           `json:"window_id"`
+	WindowOpenTime  *time.Time      `json:"window_open_time"`
+	WindowPhase     string          `json:"window_phase,omitempty"`
+	Verification    *myVerification `json:"verification"`
+	SubmittedPhrase bool            `json:"submitted_phrase"`
+}
+
+type myVerification struct {
+	ID           uint   `json:"id"`
+	Status       string `json:"status"`
+	VerifierID   uint   `json:"verifier_id"`
+	VerifierName string `json:"verifier_name"`
+}
+
+func GetMe(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var members []struct {
+		models.GameMember
+		Name string
+	}
+	if err := database.DB.Table("game_members").
+		Select("game_members.*, games.name").
+		Joins("JOIN games ON games.id = game_members.game_id AND games.deleted_at IS NULL").
+		Where("game_members.user_id = ?", userID).
+		Order("game_members.game_id asc").
+		Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch games"})
+		return
+	}
+
+	games := make([]gameStatus, 0, len(members))
+	for _, member := range members {
+		status := gameStatus{
+			GameID:       member.GameID,
+			Name:         member.Name,
+			Role:         member.Role,
+			IsEliminated: member.IsEliminated,
+			EliminatedAt: member.EliminatedAt,
+		}
+
+		if window, err := utils.GetCurrentWindow(member.GameID); err == nil {
+			status.WindowID = &window.ID
+			status.WindowOpenTime = &window.OpenTime
+			status.WindowPhase = window.Phase
+
+			// A rejected verification no longer counts, so report the one that does
+			var verification myVerification
+			if err := database.DB.Table("verifications").
+				Select("verifications.id, verifications.status, verifications.verifier_id, users.username as verifier_name").
+				Joins("JOIN users ON users.id = verifications.verifier_id").
+				Where("verifications.submission_window = ? AND verifications.verified_user_id = ? AND verifications.status <> ? AND verifications.deleted_at IS NULL",
+					window.ID, userID, models.VerificationRejected).
+				Take(&verification).Error; err == nil {
+				status.Verification = &verification
+			}
+
+			var submitted int64
+			database.DB.Model(&models.Phrase{}).
+				Where("submission_window = ? AND submitted_by = ?", window.ID, userID).
+				Count(&submitted)
+			status.SubmittedPhrase = submitted > 0
+		}
+
+		games = append(games, status)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":           user.ID,
+		"username":     user.Username,
+		"display_name": user.DisplayName,
+		"privilege":    user.Privilege,
+		"preferences":  preferencesJSON(user.Preferences),
+		"created_at":   user.CreatedAt,
+		"games":        games,
+	})
+}
+
+// UpdateMe changes the caller's display name and preferences. Preferences are
+// merged into the stored ones; a key set to null is removed.
+func UpdateMe(c *gin.Context) {
+	var input struct {
+		DisplayName *string                    `json:"display_name" binding:"omitempty,max=40"`
+		Preferences map[string]json.RawMessage `json:"preferences"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	if input.DisplayName != nil {
+		updates["display_name"] = strings.TrimSpace(*input.DisplayName)
+	}
+
+	if input.Preferences != nil {
+		preferences := map[string]json.RawMessage{}
+		if user.Preferences != "" {
+			json.Unmarshal([]byte(user.Preferences), &preferences)
+		}
+		for key, value := range input.Preferences {
+			if string(value) == "null" {
+				delete(preferences, key)
+			} else {
+				preferences[key] = value
+			}
+		}
+
+		encoded, err := json.Marshal(preferences)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid preferences"})
+			return
+		}
+		if len(encoded) > maxPreferencesSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Preferences are too large"})
+			return
+		}
+		updates["preferences"] = string(encoded)
+	}
+
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	if err := database.DB.Model(&user).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Profile updated",
+		"display_name": user.DisplayName,
+		"preferences":  preferencesJSON(user.Preferences),
+	})
+}
+
+func preferencesJSON(preferences string) json.RawMessage {
+	if preferences == "" {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(preferences)
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+type bannedWordInfo struct {
+	ID        uint      `json:"id"`
+	Word      string    `json:"word"`
+	AddedBy   uint      `json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func GetBannedWords(c *gin.Context) {
+	var words []bannedWordInfo
+	if err := database.DB.Model(&models.BannedWord{}).
+		Where("game_id = ?", c.GetUint("gameID")).
+		Order("word asc").
+		Find(&words).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch banned words"})
+		return
+	}
+
+	c.JSON(http.StatusOK, words)
+}
+
+// AddBannedWords adds words or short phrases to the game's list. Words that
+// are already banned are skipped.
+func AddBannedWords(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var input struct {
+		Words []string `json:"words" binding:"required,min=1,max=100,dive,max=64"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	words := make([]models.BannedWord, 0, len(input.Words))
+	for _, word := range input.Words {
+		// Stored normalized so they compare against normalized phrases
+		if normalized := utils.NormalizePhrase(word); normalized != "" {
+			words = append(words, models.BannedWord{GameID: gameID, Word: normalized, AddedBy: c.GetUint("userID")})
+		}
+	}
+	if len(words) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid words given"})
+		return
+	}
+
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&words)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add banned words"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Banned words added", "added": result.RowsAffected})
+}
+
+func RemoveBannedWord(c *gin.Context) {
+	result := database.DB.Where("game_id = ?", c.GetUint("gameID")).Delete(&models.BannedWord{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove banned word"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Banned word not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Banned word removed"})
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func ChangePassword(c *gin.Context) {
+	var input struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required,min=8"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := utils.ChangePassword(&user, input.CurrentPassword, input.NewPassword, c.GetUint("sessionID")); err != nil {
+		if err == utils.ErrWrongPassword {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed; other devices have been logged out"})
+}
+
+// IssueResetCode gives an admin a one-time code to pass on to a user who has
+// forgotten their password
+func IssueResetCode(c *gin.Context) {
+	var user models.User
+	if err := database.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Admins can't take over accounts at or above their own level
+	if user.Privilege >= c.GetInt("privilege") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot reset the password of a user with equal or higher privilege"})
+		return
+	}
+
+	code, expiresAt, err := utils.IssueResetCode(user.ID, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue reset code"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Reset code issued",
+		"username":   user.Username,
+		"code":       code,
+		"expires_at": expiresAt,
+	})
+}
+
+func ResetPassword(c *gin.Context) {
+	var input struct {
+		Username    string `json:"username" binding:"required"`
+		Code        string `json:"code" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=8"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := utils.RedeemResetCode(input.Username, input.Code, input.NewPassword); err != nil {
+		if err == utils.ErrResetCodeInvalid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset code"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset; log in with your new password"})
+}
@@ -1,8 +1,8 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
-	"time"
 
 	"github.com/bluefalconhd/lbd_game/server/database"
 	"github.com/bluefalconhd/lbd_game/server/models"
@@ -11,54 +11,71 @@ import (
 )
 
 func GetCurrentPhrase(c *gin.Context) {
-	var window models.SubmissionWindow
-	if err := database.DB.Order("open_time desc").First(&window).Error; err != nil {
-		c.JSON(http.StatusOK, gin.H{"phrase": nil, "message": "No active submission window"})
+	gameID := c.GetUint("gameID")
+
+	window, err := utils.GetCurrentWindow(gameID)
+	if err != nil {
+		response := gin.H{"phrase": nil, "message": "No active submission window"}
+		if next, err := utils.GetNextScheduledWindow(gameID); err == nil {
+			response["next_open_time"] = openTimeFor(next)
+		}
+		c.JSON(http.StatusOK, response)
 		return
 	}
 
 	var phrase models.Phrase
 	if err := database.DB.Where("submission_window = ?", window.ID).First(&phrase).Error; err != nil {
-		nextOpenTime := window.OpenTime
-		if time.Now().After(window.OpenTime) {
-			// If we're past the open time and no phrase exists, show next window
-			nextWindow := models.SubmissionWindow{}
-			if err := database.DB.Where("open_time > ?", window.OpenTime).Order("open_time").First(&nextWindow).Error; err == nil {
-				nextOpenTime = nextWindow.OpenTime
+		// Once this window is over without a phrase, point at the next one
+		nextOpenTime := &window.OpenTime
+		if window.Phase != models.WindowOpen {
+			if next, err := utils.GetNextScheduledWindow(gameID); err == nil {
+				nextOpenTime = openTimeFor(next)
 			}
 		}
 		c.JSON(http.StatusOK, gin.H{
 			// "phrase":         nil,
 			"message":        "No phrase submitted yet",
+			"phase":          window.Phase,
 			"next_open_time": nextOpenTime,
 		})
 		return
 	}
 
-	var user models.User
-	if err := database.DB.First(&user, phrase.SubmittedBy).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
-		return
+	// Nobody submits phrases picked from the bank
+	submittedBy := "Phrase bank"
+	if phrase.BankPhraseID == nil {
+		var user models.User
+		if err := database.DB.First(&user, phrase.SubmittedBy).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+			return
+		}
+		submittedBy = user.Username
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"phrase":            phrase.Content,
-		"submittedBy":       user.Username,
+		"submittedBy":       submittedBy,
+		"from_bank":         phrase.BankPhraseID != nil,
 		"submission_window": phrase.SubmissionWindow,
+		"phase":             window.Phase,
+		"submitted_at":      phrase.CreatedAt,
+		"latency_ms":        phrase.CreatedAt.Sub(window.OpenTime).Milliseconds(),
+		"won_by_ms":         utils.WinningMargin(&phrase),
 	})
 }
 
 func SubmitPhrase(c *gin.Context) {
 	userID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
 
-	if !utils.IsSubmissionWindowOpen() {
+	window, err := utils.GetCurrentWindow(gameID)
+	if err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Submission window is closed"})
 		return
 	}
-
-	var window models.SubmissionWindow
-	if err := database.DB.Order("open_time desc").First(&window).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No active submission window"})
+	// A taken window still goes through the claim so the caller hears by how much
+	if window.Phase != models.WindowOpen && window.Phase != models.WindowLocked {
+		respondWrongPhase(c, window, "Submission window is closed")
 		return
 	}
 
@@ -71,25 +88,57 @@ func SubmitPhrase(c *gin.Context) {
 		return
 	}
 
-	phrase := models.Phrase{
-		Content:          input.Content,
-		SubmittedBy:      userID,
-		SubmissionWindow: window.ID,
+	content, err := utils.ModeratePhrase(gameID, input.Content, window.ID)
+	if err != nil {
+		respondPhraseRejected(c, err)
+		return
 	}
 
-	if err := database.DB.Create(&phrase).Error; err != nil {
+	phrase, claimedAt, err := utils.ClaimWindow(window, userID, content)
+	if errors.Is(err, utils.ErrPhraseTaken) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":               "Someone beat you to it",
+			"code":                "phrase_taken",
+			"winner_submitted_at": phrase.CreatedAt,
+			"beaten_by_ms":        claimedAt.Sub(phrase.CreatedAt).Milliseconds(),
+		})
+		return
+	}
+	if errors.Is(err, utils.ErrWrongPhase) {
+		respondWrongPhase(c, window, "Submission window is closed")
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit phrase"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Phrase submitted successfully"})
+	utils.TriggerUpdate(gameID, utils.UpdatePhrase)
+	utils.TriggerUpdate(gameID, utils.UpdateWindow)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Phrase submitted successfully",
+		"submitted_at": phrase.CreatedAt,
+		"latency_ms":   phrase.CreatedAt.Sub(window.OpenTime).Milliseconds(),
+	})
 }
 
 func CanSubmitPhrase(c *gin.Context) {
-	if utils.IsSubmissionWindowOpen() {
+	if utils.IsSubmissionWindowOpen(c.GetUint("gameID")) {
 		c.JSON(http.StatusOK, gin.H{"can_submit": true})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"can_submit": false})
 }
+
+// respondPhraseRejected reports why moderation refused a phrase, with a code
+// the client can act on
+func respondPhraseRejected(c *gin.Context, err error) {
+	var phraseErr *utils.PhraseError
+	if errors.As(err, &phraseErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": phraseErr.Message, "code": phraseErr.Code})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check phrase"})
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Suggestions a player can have waiting for review at once
+const maxPendingSuggestions = 10
+
+type bankPhraseInfo struct {
+	ID           uint       `json:"id"`
+	Content      string     `json:"content"`
+	Status       string     `json:"status"`
+	SuggestedBy  uint       `json:"suggested_by"`
+	Username     string     `json:"username"`
+	ReviewedBy   *uint      `json:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	UsedInWindow *uint      `json:"used_in_window"`
+	UsedAt       *time.Time `json:"used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// SuggestBankPhrase lets a player propose a phrase for the bank. Admins
+// review it before it can be used.
+func SuggestBankPhrase(c *gin.Context) {
+	userID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
+
+	var input struct {
+		Content string `json:"content" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var pending int64
+	database.DB.Model(&models.BankPhrase{}).
+		Where("game_id = ? AND suggested_by = ? AND status = ?", gameID, userID, models.BankPhrasePending).
+		Count(&pending)
+	if pending >= maxPendingSuggestions {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many suggestions are waiting for review"})
+		return
+	}
+
+	content, err := utils.ModerateBankPhrase(gameID, input.Content)
+	if err != nil {
+		respondPhraseRejected(c, err)
+		return
+	}
+
+	entry := models.BankPhrase{
+		GameID:      gameID,
+		Content:     content,
+		Status:      models.BankPhrasePending,
+		SuggestedBy: userID,
+	}
+	if err := database.DB.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save suggestion"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Suggestion sent for review", "id": entry.ID})
+}
+
+// GetBankPhrases lists the game's bank, optionally only entries with the
+// given status. Unused entries come first.
+func GetBankPhrases(c *gin.Context) {
+	query := database.DB.Table("bank_phrases").
+		Select("bank_phrases.*, COALESCE(users.username, '') as username").
+		Joins("LEFT JOIN users ON users.id = bank_phrases.suggested_by").
+		Where("bank_phrases.game_id = ? AND bank_phrases.deleted_at IS NULL", c.GetUint("gameID"))
+
+	if status := c.Query("status"); status != "" {
+		switch status {
+		case models.BankPhrasePending, models.BankPhraseApproved, models.BankPhraseRejected:
+			query = query.Where("bank_phrases.status = ?", status)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+	}
+
+	phrases := []bankPhraseInfo{}
+	if err := query.
+		Order("bank_phrases.used_in_window IS NOT NULL, bank_phrases.created_at desc").
+		Find(&phrases).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch phrase bank"})
+		return
+	}
+
+	c.JSON(http.StatusOK, phrases)
+}
+
+// AddBankPhrase adds an approved phrase to the bank
+func AddBankPhrase(c *gin.Context) {
+	adminID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
+
+	var input struct {
+		Content string `json:"content" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	content, err := utils.ModerateBankPhrase(gameID, input.Content)
+	if err != nil {
+		respondPhraseRejected(c, err)
+		return
+	}
+
+	now := utils.Now()
+	entry := models.BankPhrase{
+		GameID:      gameID,
+		Content:     content,
+		Status:      models.BankPhraseApproved,
+		SuggestedBy: adminID,
+		ReviewedBy:  &adminID,
+		ReviewedAt:  &now,
+	}
+	if err := database.DB.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add phrase"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Phrase added to the bank", "id": entry.ID})
+}
+
+// ReviewBankPhrase approves or rejects a suggestion
+func ReviewBankPhrase(c *gin.Context) {
+	adminID := c.GetUint("userID")
+
+	var input struct {
+		Decision string `json:"decision" binding:"required,oneof=approve reject"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var entry models.BankPhrase
+	if err := database.DB.Where("game_id = ?", c.GetUint("gameID")).First(&entry, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Phrase not found"})
+		return
+	}
+
+	if entry.UsedInWindow != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phrase has already been used"})
+		return
+	}
+
+	status := models.BankPhraseApproved
+	if input.Decision == "reject" {
+		status = models.BankPhraseRejected
+	}
+
+	if err := database.DB.Model(&entry).Updates(map[string]interface{}{
+		"status":      status,
+		"reviewed_by": adminID,
+		"reviewed_at": utils.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review phrase"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Phrase reviewed", "status": status})
+}
+
+// RemoveBankPhrase deletes an entry from the bank. Windows that already used
+// it keep their phrase.
+func RemoveBankPhrase(c *gin.Context) {
+	result := database.DB.Where("game_id = ?", c.GetUint("gameID")).Delete(&models.BankPhrase{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove phrase"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Phrase not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Phrase removed from the bank"})
+}
@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bluefalconhd/lbd_game/server/config"
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func scheduleResponse(schedule *models.GameSchedule) gin.H {
+	skipDates := config.SplitList(schedule.SkipDates)
+	if skipDates == nil {
+		skipDates = []string{}
+	}
+
+	return gin.H{
+		"timezone":   schedule.Timezone,
+		"open_start": schedule.OpenStart,
+		"open_end":   schedule.OpenEnd,
+		"close_at":   schedule.CloseAt,
+		"cadence":    schedule.Cadence,
+		"cron_expr":  schedule.CronExpr,
+		"skip_dates": skipDates,
+	}
+}
+
+func GetSchedule(c *gin.Context) {
+	schedule := utils.GetGameSchedule(c.GetUint("gameID"))
+	c.JSON(http.StatusOK, scheduleResponse(&schedule))
+}
+
+func UpdateSchedule(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var input struct {
+		Timezone  *string   `json:"timezone"`
+		OpenStart *string   `json:"open_start"`
+		OpenEnd   *string   `json:"open_end"`
+		CloseAt   *string   `json:"close_at"`
+		Cadence   *string   `json:"cadence"`
+		CronExpr  *string   `json:"cron_expr"`
+		SkipDates *[]string `json:"skip_dates"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule := utils.GetGameSchedule(gameID)
+	if input.Timezone != nil {
+		schedule.Timezone = *input.Timezone
+	}
+	if input.OpenStart != nil {
+		schedule.OpenStart = *input.OpenStart
+	}
+	if input.OpenEnd != nil {
+		schedule.OpenEnd = *input.OpenEnd
+	}
+	if input.CloseAt != nil {
+		schedule.CloseAt = *input.CloseAt
+	}
+	if input.Cadence != nil {
+		schedule.Cadence = *input.Cadence
+	}
+	if input.CronExpr != nil {
+		schedule.CronExpr = *input.CronExpr
+	}
+	if input.SkipDates != nil {
+		schedule.SkipDates = strings.Join(*input.SkipDates, ",")
+	}
+
+	if _, err := utils.CompileSchedule(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Save(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save schedule"})
+		return
+	}
+
+	// The upcoming window may no longer fit, so plan it again
+	var game models.Game
+	if err := database.DB.First(&game, gameID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		return
+	}
+
+	if err := utils.RescheduleGame(&game); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule upcoming window"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule updated successfully", "schedule": scheduleResponse(&schedule)})
+}
+
+func overrideResponse(rule *models.ScheduleOverride) gin.H {
+	return gin.H{
+		"id":         rule.ID,
+		"kind":       rule.Kind,
+		"name":       rule.Name,
+		"start_date": rule.StartDate,
+		"end_date":   rule.EndDate,
+		"recurrence": rule.Recurrence,
+		"until":      rule.Until,
+		"time":       rule.Time,
+		"open_start": rule.OpenStart,
+		"open_end":   rule.OpenEnd,
+		"created_by": rule.CreatedBy,
+		"created_at": rule.CreatedAt,
+	}
+}
+
+// GetScheduleOverrides lists the game's override rules, optionally only those
+// of one kind. Filtering on holiday gives the holiday calendar.
+func GetScheduleOverrides(c *gin.Context) {
+	query := database.DB.Where("game_id = ?", c.GetUint("gameID"))
+
+	if kind := c.Query("kind"); kind != "" {
+		switch kind {
+		case models.OverrideSkip, models.OverrideHoliday, models.OverrideFixedTime, models.OverrideRange:
+			query = query.Where("kind = ?", kind)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kind"})
+			return
+		}
+	}
+
+	var rules []models.ScheduleOverride
+	if err := query.Order("start_date asc, id asc").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule overrides"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(rules))
+	for i := range rules {
+		response = append(response, overrideResponse(&rules[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateScheduleOverride adds a rule the scheduler follows from now on. The
+// upcoming window is planned again only if the rule reaches it.
+func CreateScheduleOverride(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var input struct {
+		Kind       string `json:"kind" binding:"required"`
+		Name       string `json:"name"`
+		StartDate  string `json:"start_date" binding:"required"`
+		EndDate    string `json:"end_date"`
+		Recurrence string `json:"recurrence"`
+		Until      string `json:"until"`
+		Time       string `json:"time"`
+		OpenStart  string `json:"open_start"`
+		OpenEnd    string `json:"open_end"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := models.ScheduleOverride{
+		GameID:     gameID,
+		Kind:       input.Kind,
+		Name:       strings.TrimSpace(input.Name),
+		StartDate:  input.StartDate,
+		EndDate:    input.EndDate,
+		Recurrence: input.Recurrence,
+		Until:      input.Until,
+		Time:       input.Time,
+		OpenStart:  input.OpenStart,
+		OpenEnd:    input.OpenEnd,
+		CreatedBy:  c.GetUint("userID"),
+	}
+	if rule.EndDate == "" {
+		rule.EndDate = rule.StartDate
+	}
+
+	schedule, err := utils.LoadSchedule(gameID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load schedule"})
+		return
+	}
+	if err := schedule.AddOverride(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var game models.Game
+	if err := database.DB.First(&game, gameID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		return
+	}
+
+	if err := database.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save schedule override"})
+		return
+	}
+
+	if err := utils.RescheduleForOverride(&game, &rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule upcoming window"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Schedule override added", "override": overrideResponse(&rule)})
+}
+
+// DeleteScheduleOverride removes a rule, planning the upcoming window again
+// if the rule reached it
+func DeleteScheduleOverride(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var rule models.ScheduleOverride
+	if err := database.DB.Where("game_id = ?", gameID).First(&rule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule override not found"})
+		return
+	}
+
+	var game models.Game
+	if err := database.DB.First(&game, gameID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		return
+	}
+
+	if err := database.DB.Delete(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule override"})
+		return
+	}
+
+	if err := utils.RescheduleForOverride(&game, &rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule upcoming window"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule override deleted"})
+}
+
+// PreviewSchedule lists the coming days with whether each gets a window, the
+// range it may open in and the override deciding it, if any
+func PreviewSchedule(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "14"))
+	if err != nil || days < 1 || days > 366 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 366"})
+		return
+	}
+
+	schedule, err := utils.LoadSchedule(c.GetUint("gameID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load schedule"})
+		return
+	}
+
+	today := schedule.LocalDay(utils.Now())
+	preview := make([]gin.H, 0, days)
+	for i := 0; i < days; i++ {
+		day := today.AddDate(0, 0, i)
+		eligible := schedule.IsEligible(day)
+		entry := gin.H{"date": day.Format("2006-01-02"), "window": eligible}
+		if eligible {
+			start, end := schedule.OpenRange(day)
+			entry["open_start"] = start
+			entry["open_end"] = end
+		}
+		if rule, ok := schedule.OverrideOn(day); ok {
+			entry["override"] = overrideResponse(&rule)
+		}
+		preview = append(preview, entry)
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type seasonSummary struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	StartedAt  time.Time  `json:"started_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+	EndReason  string     `json:"end_reason"`
+	WinnerID   *uint      `json:"winner_id"`
+	WinnerName *string    `json:"winner_name"`
+}
+
+func seasonsQuery(gameID uint) *gorm.DB {
+	return database.DB.Table("seasons").
+		Select("seasons.id, seasons.name, seasons.started_at, seasons.ends_at, seasons.ended_at, "+
+			"seasons.end_reason, seasons.winner_id, users.username as winner_name").
+		Joins("LEFT JOIN users ON users.id = seasons.winner_id").
+		Where("seasons.game_id = ? AND seasons.deleted_at IS NULL", gameID)
+}
+
+func StartSeason(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var input struct {
+		Name   string `json:"name" binding:"required,max=64"`
+		EndsAt *int64 `json:"ends_at"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var endsAt *time.Time
+	if input.EndsAt != nil {
+		t := time.Unix(*input.EndsAt, 0).UTC()
+		if t.Before(utils.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "End time must be in the future"})
+			return
+		}
+		endsAt = &t
+	}
+
+	season, err := utils.StartSeason(gameID, strings.TrimSpace(input.Name), endsAt)
+	if errors.Is(err, utils.ErrSeasonRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A season is already running"})
+		return
+	}
+	if errors.Is(err, utils.ErrTooFewPlayers) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A season needs at least two members"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start season"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Season started", "season_id": season.ID})
+}
+
+func EndCurrentSeason(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var input struct {
+		WinnerID *uint `json:"winner_id"`
+	}
+
+	// Declaring a winner is optional, so an empty body is fine
+	if c.Request.Body != http.NoBody {
+		if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	season, err := utils.GetActiveSeason(gameID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No season is running"})
+		return
+	}
+
+	if input.WinnerID != nil {
+		if _, err := utils.GetMembership(gameID, *input.WinnerID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Winner is not a member of this game"})
+			return
+		}
+	}
+
+	err = utils.EndSeason(season, input.WinnerID, models.SeasonEndAdmin)
+	if errors.Is(err, utils.ErrSeasonEnded) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The season has already ended"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end season"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Season ended", "season_id": season.ID})
+}
+
+func GetSeasons(c *gin.Context) {
+	var seasons []seasonSummary
+	if err := seasonsQuery(c.GetUint("gameID")).Order("seasons.started_at desc").Find(&seasons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch seasons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, seasons)
+}
+
+func GetSeason(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	var season seasonSummary
+	result := seasonsQuery(gameID).Where("seasons.id = ?", c.Param("seasonID")).Limit(1).Find(&season)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch season"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Season not found"})
+		return
+	}
+
+	// Running seasons are ranked live, ended ones come from the archive
+	var standings []utils.Standing
+	var err error
+	if season.EndedAt == nil {
+		standings, err = utils.ComputeStandings(gameID, season.ID)
+	} else {
+		err = database.DB.Table("season_results").
+			Select("season_results.rank, season_results.user_id, users.username, season_results.is_eliminated, "+
+				"season_results.eliminated_at, season_results.verifications_received, "+
+				"season_results.verifications_given, season_results.phrases_submitted").
+			Joins("JOIN users ON users.id = season_results.user_id").
+			Where("season_results.season_id = ?", season.ID).
+			Order("season_results.rank asc").
+			Find(&standings).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch standings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"season": season, "standings": standings})
+}
+
+func GetCurrentStandings(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	season, err := utils.GetActiveSeason(gameID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "No season is running"})
+		return
+	}
+
+	standings, err := utils.ComputeStandings(gameID, season.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute standings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"season_id": season.ID, "name": season.Name, "standings": standings})
+}
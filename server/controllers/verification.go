@@ -1,79 +1,234 @@
 package controllers
 
 import (
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"time"
 
 	"github.com/bluefalconhd/lbd_game/server/database"
 	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func VerifyUser(c *gin.Context) {
 	verifierID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
 
 	// Get current submission window
-	var window models.SubmissionWindow
-	if err := database.DB.Order("open_time desc").First(&window).Error; err != nil {
+	window, err := utils.GetCurrentWindow(gameID)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No active submission window"})
 		return
 	}
+	if window.Phase != models.WindowVerifying {
+		respondWrongPhase(c, window, "Verification is not open")
+		return
+	}
 
+	// Accepts JSON, or a multipart form when evidence files are attached
 	var input struct {
-		VerifiedUserID uint `json:"verified_user_id" binding:"required"`
+		VerifiedUserID uint   `json:"verified_user_id" form:"verified_user_id" binding:"required"`
+		ContextNote    string `json:"context_note" form:"context_note" binding:"max=1000"`
+		HeardAt        *int64 `json:"heard_at" form:"heard_at"`
 	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
+	if err := c.ShouldBind(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Check if verification already exists for this window
-	var existingVerification models.Verification
-	if err := database.DB.Where("verified_user_id = ? AND submission_window = ?",
-		input.VerifiedUserID, window.ID).First(&existingVerification).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "User has already been verified in this window"})
+	var heardAt *time.Time
+	if input.HeardAt != nil {
+		t := time.Unix(*input.HeardAt, 0).UTC()
+		if t.After(utils.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "heard_at cannot be in the future"})
+			return
+		}
+		heardAt = &t
+	}
+
+	var files []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		files = form.File["evidence"]
+	}
+	if len(files) > maxEvidenceFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d evidence files can be attached", maxEvidenceFiles)})
+		return
+	}
+
+	if input.VerifiedUserID == verifierID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot verify yourself"})
+		return
+	}
+
+	// Only members of this game can be verified in it
+	member, err := utils.GetMembership(gameID, input.VerifiedUserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this game"})
+		return
+	}
+
+	if member.IsEliminated {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User has been eliminated"})
 		return
 	}
 
 	verification := models.Verification{
+		GameID:           gameID,
 		VerifiedUserID:   input.VerifiedUserID,
 		VerifierID:       verifierID,
 		SubmissionWindow: window.ID,
+		Status:           models.VerificationPending,
+		ContextNote:      input.ContextNote,
+		HeardAt:          heardAt,
+		CreatedAt:        utils.Now(),
 	}
 
-	if err := database.DB.Create(&verification).Error; err != nil {
+	attachments, err := saveEvidence(files, verifierID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// The unique index turns away a second verification of the player in
+		// this window, even when both are reported at once
+		if err := tx.Create(&verification).Error; err != nil {
+			return err
+		}
+
+		for i := range attachments {
+			attachments[i].VerificationID = verification.ID
+			if err := tx.Create(&attachments[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		// The verifier's claim is the first confirmation
+		if err := tx.Create(&models.VerificationConfirmation{
+			VerificationID: verification.ID,
+			UserID:         verifierID,
+			CreatedAt:      verification.CreatedAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		return utils.SettleVerification(tx, &verification)
+	})
+	if err != nil {
+		removeEvidence(attachments)
+		if utils.HasStandingVerification(window.ID, input.VerifiedUserID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "User has already been verified in this window"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record verification"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Verification recorded"})
+	utils.TriggerUpdate(gameID, utils.UpdateVerification)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":         "Verification recorded",
+		"verification_id": verification.ID,
+		"status":          verification.Status,
+		"attachments":     len(attachments),
+	})
+}
+
+func ConfirmVerification(c *gin.Context) {
+	userID := c.GetUint("userID")
+	gameID := c.GetUint("gameID")
+
+	var verification models.Verification
+	if err := database.DB.Where("game_id = ?", gameID).First(&verification, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Verification not found"})
+		return
+	}
+
+	if verification.Status != models.VerificationPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending verifications can be confirmed"})
+		return
+	}
+
+	if verification.VerifiedUserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot confirm your own verification"})
+		return
+	}
+
+	window, err := utils.GetWindow(verification.SubmissionWindow)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Window not found"})
+		return
+	}
+	if window.Phase != models.WindowVerifying {
+		respondWrongPhase(c, window, "Verification is not open")
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.VerificationConfirmation{}).
+		Where("verification_id = ? AND user_id = ?", verification.ID, userID).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already confirmed this verification"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.VerificationConfirmation{
+			VerificationID: verification.ID,
+			UserID:         userID,
+			CreatedAt:      utils.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return utils.SettleVerification(tx, &verification)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm verification"})
+		return
+	}
+
+	utils.TriggerUpdate(gameID, utils.UpdateVerification)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification confirmed", "status": verification.Status})
 }
 
 func GetCurrentVerifications(c *gin.Context) {
-	var window models.SubmissionWindow
-	if err := database.DB.Order("open_time desc").First(&window).Error; err != nil {
+	window, err := utils.GetCurrentWindow(c.GetUint("gameID"))
+	if err != nil {
 		c.JSON(http.StatusOK, gin.H{"message": "No active submission window"})
 		return
 	}
 
 	var verifications []struct {
-		VerificationID   uint      `json:"verification_id"`
-		VerifierID       uint      `json:"verifier_id"`
-		VerifierName     string    `json:"verifier_name"`
-		VerifiedID       uint      `json:"verified_id"`
-		VerifiedName     string    `json:"verified_name"`
-		SubmissionWindow uint      `json:"submission_window"`
-		CreatedAt        time.Time `json:"created_at"`
+		VerificationID   uint             `json:"verification_id"`
+		VerifierID       uint             `json:"verifier_id"`
+		VerifierName     string           `json:"verifier_name"`
+		VerifiedID       uint             `json:"verified_id"`
+		VerifiedName     string           `json:"verified_name"`
+		SubmissionWindow uint             `json:"submission_window"`
+		Status           string           `json:"status"`
+		Confirmations    int64            `json:"confirmations"`
+		ContextNote      string           `json:"context_note"`
+		HeardAt          *time.Time       `json:"heard_at"`
+		Attachments      []attachmentInfo `json:"attachments" gorm:"-"`
+		CreatedAt        time.Time        `json:"created_at"`
 	}
 
 	result := database.DB.Table("verifications").
 		Select("verifications.id as verification_id, verifications.verifier_id, "+
 			"u1.username as verifier_name, verifications.verified_user_id as verified_id, "+
-			"u2.username as verified_name, verifications.submission_window, verifications.created_at").
+			"u2.username as verified_name, verifications.submission_window, verifications.status, "+
+			"(SELECT COUNT(*) FROM verification_confirmations WHERE verification_confirmations.verification_id = verifications.id) as confirmations, "+
+			"verifications.context_note, verifications.heard_at, verifications.created_at").
 		Joins("JOIN users u1 ON verifications.verifier_id = u1.id").
 		Joins("JOIN users u2 ON verifications.verified_user_id = u2.id").
-		Where("verifications.submission_window = ?", window.ID).
+		Where("verifications.submission_window = ? AND verifications.deleted_at IS NULL", window.ID).
 		Find(&verifications)
 
 	if result.Error != nil {
@@ -81,12 +236,30 @@ func GetCurrentVerifications(c *gin.Context) {
 		return
 	}
 
+	ids := make([]uint, len(verifications))
+	for i, v := range verifications {
+		ids[i] = v.VerificationID
+	}
+	attachments, err := attachmentsFor(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+		return
+	}
+	for i := range verifications {
+		verifications[i].Attachments = attachments[verifications[i].VerificationID]
+		if verifications[i].Attachments == nil {
+			verifications[i].Attachments = []attachmentInfo{}
+		}
+	}
+
 	c.JSON(http.StatusOK, verifications)
 }
 
 func GetUnverifiedUsers(c *gin.Context) {
-	var window models.SubmissionWindow
-	if err := database.DB.Order("open_time desc").First(&window).Error; err != nil {
+	gameID := c.GetUint("gameID")
+
+	window, err := utils.GetCurrentWindow(gameID)
+	if err != nil {
 		c.JSON(http.StatusOK, gin.H{"message": "No active submission window"})
 		return
 	}
@@ -97,11 +270,13 @@ func GetUnverifiedUsers(c *gin.Context) {
 	}
 
 	result := database.DB.Table("users").
-		Select("id, username").
-		Where("id NOT IN (?)",
+		Select("users.id, users.username").
+		Joins("JOIN game_members ON game_members.user_id = users.id AND game_members.game_id = ?", gameID).
+		Where("users.deleted_at IS NULL").
+		Where("users.id NOT IN (?)",
 			database.DB.Table("verifications").
 				Select("verified_user_id").
-				Where("submission_window = ?", window.ID)).
+				Where("submission_window = ? AND status <> ? AND deleted_at IS NULL", window.ID, models.VerificationRejected)).
 		Find(&unverifiedUsers)
 
 	if result.Error != nil {
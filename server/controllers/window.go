@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func windowResponse(window *models.SubmissionWindow) gin.H {
+	response := gin.H{
+		"id":           window.ID,
+		"phase":        window.Phase,
+		"open_time":    openTimeFor(window),
+		"close_time":   window.CloseTime,
+		"opened_at":    window.OpenedAt,
+		"locked_at":    window.LockedAt,
+		"verifying_at": window.VerifyingAt,
+		"closed_at":    window.ClosedAt,
+		"scored_at":    window.ScoredAt,
+		"hidden":       window.Hidden,
+		"range_start":  window.RangeStart,
+		"range_end":    window.RangeEnd,
+		"commitment":   window.Commitment,
+		"seed":         nil,
+	}
+	if utils.SeedRevealed(window) {
+		response["seed"] = window.Seed
+	}
+	return response
+}
+
+// openTimeFor is the window's open time, or nil while it is hidden
+func openTimeFor(window *models.SubmissionWindow) *time.Time {
+	if window.Hidden && !window.DeletedAt.Valid && utils.Now().Before(window.OpenTime) {
+		return nil
+	}
+	return &window.OpenTime
+}
+
+// GetCurrentWindow reports the latest window to open and the phase it is
+// in, along with the next one
+func GetCurrentWindow(c *gin.Context) {
+	gameID := c.GetUint("gameID")
+
+	response := gin.H{"window": nil, "next_window": nil, "next_open_time": nil}
+	if window, err := utils.GetCurrentWindow(gameID); err == nil {
+		response["window"] = windowResponse(window)
+	}
+	if next, err := utils.GetNextScheduledWindow(gameID); err == nil {
+		response["next_window"] = windowResponse(next)
+		response["next_open_time"] = openTimeFor(next)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWindowAudit shows how a window's open time was chosen. Once it opens or
+// is cancelled the seed is included, so anyone can recompute the commitment
+// and the open time from it.
+func GetWindowAudit(c *gin.Context) {
+	// Cancelled windows keep their commitment on record, so they can be
+	// audited too
+	var window models.SubmissionWindow
+	if err := database.DB.Unscoped().Where("game_id = ?", c.GetUint("gameID")).First(&window, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Window not found"})
+		return
+	}
+
+	var cancelledAt *time.Time
+	if window.DeletedAt.Valid {
+		cancelledAt = &window.DeletedAt.Time
+	}
+
+	response := gin.H{
+		"window_id":    window.ID,
+		"cancelled_at": cancelledAt,
+		"method":       "committed_seed",
+		"commitment":   window.Commitment,
+		"range_start":  window.RangeStart,
+		"range_end":    window.RangeEnd,
+		"open_time":    openTimeFor(&window),
+		"seed":         nil,
+		"verified":     nil,
+		"algorithm": "commitment = hex(sha256(\"commitment:\" + seed)); " +
+			"open_time = range_start + (big-endian uint64 of sha256(\"open_time:\" + seed)[:8] mod (range_end - range_start in seconds)) seconds",
+	}
+	if window.Commitment == "" {
+		// Placed by an admin or planned before open times were committed
+		response["method"] = "manual"
+	}
+	if utils.SeedRevealed(&window) {
+		response["seed"] = window.Seed
+		response["verified"] = utils.VerifyOpenTime(&window)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// respondWrongPhase refuses an action the window's current phase doesn't allow
+func respondWrongPhase(c *gin.Context, window *models.SubmissionWindow, message string) {
+	c.JSON(http.StatusForbidden, gin.H{"error": message, "code": "wrong_phase", "phase": window.Phase})
+}
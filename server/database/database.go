@@ -2,6 +2,7 @@ package database
 
 import (
 	"log"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -12,12 +13,40 @@ import (
 var DB *gorm.DB
 
 func ConnectDatabase() {
-	database, err := gorm.Open(sqlite.Open("game.db"), &gorm.Config{})
+	// Writers wait for the lock instead of failing with "database is locked",
+	// and transactions take it up front so concurrent ones queue rather than
+	// deadlock when upgrading from a read.
+	// SQLite compares times as text, so everything is stored in UTC
+	database, err := gorm.Open(sqlite.Open("game.db?_busy_timeout=5000&_txlock=immediate"), &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	database.AutoMigrate(&models.User{}, &models.Phrase{}, &models.Verification{}, &models.SubmissionWindow{})
+	// Windows could get two phrases before they were unique per window; keep
+	// the first submitted so the index can be created
+	if database.Migrator().HasTable(&models.Phrase{}) {
+		database.Exec(`UPDATE phrases SET deleted_at = ? WHERE deleted_at IS NULL AND EXISTS (
+			SELECT 1 FROM phrases AS earlier
+			WHERE earlier.submission_window = phrases.submission_window AND earlier.deleted_at IS NULL
+			AND (earlier.created_at < phrases.created_at OR (earlier.created_at = phrases.created_at AND earlier.id < phrases.id)))`,
+			time.Now().UTC())
+	}
+
+	// Likewise players could be verified twice in a window when two verifiers
+	// reported them at once; keep the first report
+	if database.Migrator().HasTable(&models.Verification{}) {
+		database.Exec(`UPDATE verifications SET deleted_at = ? WHERE deleted_at IS NULL AND status <> 'rejected' AND EXISTS (
+			SELECT 1 FROM verifications AS earlier
+			WHERE earlier.submission_window = verifications.submission_window
+			AND earlier.verified_user_id = verifications.verified_user_id
+			AND earlier.deleted_at IS NULL AND earlier.status <> 'rejected'
+			AND (earlier.created_at < verifications.created_at OR (earlier.created_at = verifications.created_at AND earlier.id < verifications.id)))`,
+			time.Now().UTC())
+	}
+
+	database.AutoMigrate(&models.User{}, &models.Phrase{}, &models.Verification{}, &models.SubmissionWindow{}, &models.Elimination{}, &models.Game{}, &models.GameMember{}, &models.GameSchedule{}, &models.Season{}, &models.SeasonResult{}, &models.VerificationConfirmation{}, &models.Dispute{}, &models.VerificationAttachment{}, &models.Session{}, &models.PasswordReset{}, &models.SignupInvite{}, &models.FailedLogin{}, &models.BannedWord{}, &models.SubmissionAttempt{}, &models.BankPhrase{}, &models.JobRun{}, &models.ScheduleOverride{})
 
 	DB = database
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"log"
+
 	"github.com/bluefalconhd/lbd_game/server/config"
 	"github.com/bluefalconhd/lbd_game/server/database"
 	"github.com/bluefalconhd/lbd_game/server/routes"
@@ -10,12 +12,31 @@ import (
 )
 
 func main() {
-	database.ConnectDatabase()
-	utils.InitScheduler()
-
 	godotenv.Load()
 	cfg := config.LoadConfig()
 
+	if err := utils.InitJWT(cfg); err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
+	}
+
+	if err := utils.SetRegistrationMode(cfg.RegistrationMode); err != nil {
+		log.Fatal(err)
+	}
+	if err := utils.SetPhraseLimits(cfg); err != nil {
+		log.Fatal(err)
+	}
+
+	database.ConnectDatabase()
+	utils.InitClock(cfg)
+	utils.InitPhraseSearch()
+	if err := utils.MigrateDefaultGame(); err != nil {
+		log.Fatal("Failed to migrate existing data into a game:", err)
+	}
+	if err := utils.InitUploads(cfg); err != nil {
+		log.Fatal("Failed to prepare upload directory:", err)
+	}
+	utils.InitScheduler(cfg)
+
 	router := routes.SetupRouter(cfg)
 	router.Run(":8040")
 }
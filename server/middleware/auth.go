@@ -21,8 +21,17 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Sessions can be revoked and privileges change, so check both against
+		// the database rather than trusting the token
+		privilege, err := utils.AuthenticateSession(claims.UserID, claims.SessionID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has expired or been revoked"})
+			return
+		}
+
 		c.Set("userID", claims.UserID)
-		c.Set("privilege", claims.Privilege)
+		c.Set("privilege", privilege)
+		c.Set("sessionID", claims.SessionID)
 
 		c.Next()
 	}
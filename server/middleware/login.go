@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// LoginThrottleMiddleware slows down and locks out repeated failed logins, per
+// client IP and per username. It wraps the login handler, which signals a
+// failed attempt by responding 401.
+func LoginThrottleMiddleware(byIP, byUsername *utils.LoginThrottle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Peek at the username, leaving the body for the handler
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<16))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var input struct {
+			Username string `json:"username"`
+		}
+		json.Unmarshal(body, &input)
+
+		ip := c.ClientIP()
+		username := strings.ToLower(strings.TrimSpace(input.Username))
+
+		wait := byIP.Wait(ip)
+		if username != "" {
+			if userWait := byUsername.Wait(username); userWait > wait {
+				wait = userWait
+			}
+		}
+		if wait > 0 {
+			utils.RecordFailedLogin(input.Username, nil, ip, c.Request.UserAgent(), models.LoginThrottled)
+
+			seconds := int(math.Ceil(wait.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       fmt.Sprintf("Too many failed attempts, try again in %d seconds", seconds),
+				"retry_after": seconds,
+			})
+			return
+		}
+
+		c.Next()
+
+		switch c.Writer.Status() {
+		case http.StatusUnauthorized:
+			byIP.Fail(ip)
+			if username != "" {
+				byUsername.Fail(username)
+			}
+		case http.StatusOK:
+			byUsername.Reset(username)
+			byIP.Forgive(ip)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Review states of a phrase bank entry
+const (
+	BankPhrasePending  = "pending"
+	BankPhraseApproved = "approved"
+	BankPhraseRejected = "rejected"
+)
+
+// BankPhrase is a phrase held in reserve for windows nobody claims. Admins add
+// approved entries directly; suggestions from players wait for review.
+type BankPhrase struct {
+	ID           uint   `gorm:"primaryKey"`
+	GameID       uint   `gorm:"not null;index"`
+	Content      string `gorm:"not null"`
+	Status       string `gorm:"not null;default:'pending';index"`
+	SuggestedBy  uint   `gorm:"not null"`
+	ReviewedBy   *uint  // Admin who approved or rejected it
+	ReviewedAt   *time.Time
+	UsedInWindow *uint // Window it was picked for, each entry is used once
+	UsedAt       *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// BannedWord is a word or phrase a game doesn't allow in submitted phrases.
+// Words are stored normalized, as compared.
+type BannedWord struct {
+	ID        uint   `gorm:"primaryKey"`
+	GameID    uint   `gorm:"not null;uniqueIndex:idx_banned_word"`
+	Word      string `gorm:"not null;uniqueIndex:idx_banned_word"`
+	AddedBy   uint   `gorm:"not null"`
+	CreatedAt time.Time
+}
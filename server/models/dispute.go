@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Dispute states
+const (
+	DisputeOpen      = "open"
+	DisputeUpheld    = "upheld"    // The verification was rejected
+	DisputeDismissed = "dismissed" // The verification stands
+)
+
+type Dispute struct {
+	ID             uint   `gorm:"primaryKey"`
+	GameID         uint   `gorm:"not null;index"`
+	VerificationID uint   `gorm:"not null;index"`
+	RaisedBy       uint   `gorm:"not null"`
+	Reason         string `gorm:"not null"`
+	Status         string `gorm:"not null;default:'open';index"`
+	ResolvedBy     *uint
+	ResolvedAt     *time.Time
+	Resolution     string // Admin's note on the decision
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Elimination struct {
+	ID               uint   `gorm:"primaryKey"`
+	GameID           uint   `gorm:"not null;default:0;index"`
+	UserID           uint   `gorm:"not null;index"`
+	SubmissionWindow uint   `gorm:"not null;index"`
+	Reason           string `gorm:"not null"`
+	ResurrectedBy    *uint  // Admin who undid the elimination
+	ResurrectedAt    *time.Time
+	ResurrectNote    string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// Reasons a login attempt failed
+const (
+	LoginUnknownUser = "unknown_user"
+	LoginBadPassword = "bad_password"
+	LoginThrottled   = "throttled" // Rejected before the password was checked
+)
+
+type FailedLogin struct {
+	ID        uint   `gorm:"primaryKey"`
+	Username  string `gorm:"not null;index"`
+	UserID    *uint  `gorm:"index"` // Nil when no account has the username
+	IPAddress string `gorm:"not null;index"`
+	UserAgent string
+	Reason    string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Roles a member can hold within a game
+const (
+	RolePlayer = 0
+	RoleAdmin  = 1
+	RoleOwner  = 2
+)
+
+type Game struct {
+	ID                    uint   `gorm:"primaryKey"`
+	Name                  string `gorm:"not null"`
+	InviteCode            string `gorm:"uniqueIndex;not null"`
+	CreatedBy             uint   `gorm:"not null"`
+	EliminationRules      string // Comma separated, empty uses the server default
+	RequiredConfirmations int    `gorm:"not null;default:1"`     // Needed before a verification counts, including the verifier's own
+	PhraseDeadline        int    `gorm:"not null;default:60"`    // Minutes after opening before a bank phrase fills an unclaimed window, 0 never does
+	PhraseLock            int    `gorm:"not null;default:5"`     // Minutes admins can correct a claimed phrase before verification opens
+	HideOpenTimes         bool   `gorm:"not null;default:false"` // Windows planned while set keep their open time secret, admins included
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	DeletedAt             gorm.DeletedAt `gorm:"index"`
+}
+
+type GameMember struct {
+	ID                uint       `gorm:"primaryKey"`
+	GameID            uint       `gorm:"not null;uniqueIndex:idx_game_member"`
+	UserID            uint       `gorm:"not null;uniqueIndex:idx_game_member"`
+	Role              int        `gorm:"not null;default:0"` // 0: Player, 1: Admin, 2: Owner
+	IsEliminated      bool       `gorm:"not null;default:false"`
+	EliminatedAt      *time.Time // Set by the elimination pass
+	EliminationReason string     // Rule that eliminated the member
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+type GameSchedule struct {
+	ID        uint   `gorm:"primaryKey"`
+	GameID    uint   `gorm:"not null;uniqueIndex"`
+	Timezone  string `gorm:"not null"` // IANA name, e.g. America/Chicago
+	OpenStart string `gorm:"not null"` // HH:MM local time
+	OpenEnd   string `gorm:"not null"` // HH:MM local time
+	CloseAt   string // HH:MM local time windows close on the day they open, empty for midnight
+	Cadence   string `gorm:"not null"` // daily, weekdays or cron
+	CronExpr  string // Days the expression fires on get a window when Cadence is cron
+	SkipDates string // Comma separated YYYY-MM-DD dates without a window
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
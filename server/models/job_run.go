@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// JobRun records one run of a scheduled job. Each job runs at most once per
+// scheduled time, which is what makes replaying missed runs safe.
+type JobRun struct {
+	ID           uint      `gorm:"primaryKey"`
+	Job          string    `gorm:"not null;uniqueIndex:idx_job_run"`
+	ScheduledFor time.Time `gorm:"not null;uniqueIndex:idx_job_run"` // The time the run stands for, in the past when replayed
+	Replayed     bool      `gorm:"not null;default:false"`
+	StartedAt    time.Time `gorm:"not null"`
+	FinishedAt   *time.Time
+	Error        string // Empty when the run succeeded
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// PasswordReset is a one-time code an admin hands to a user who is locked out.
+// Only a hash of the code is stored.
+type PasswordReset struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	CodeHash  string    `gorm:"not null;uniqueIndex"`
+	IssuedBy  uint      `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	Attempts  int       `gorm:"not null;default:0"` // Wrong codes tried against this reset
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
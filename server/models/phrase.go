@@ -8,9 +8,11 @@ import (
 
 type Phrase struct {
     ID                uint           `gorm:"primaryKey"`
+    GameID           uint           `gorm:"not null;default:0;index"`
     Content          string         `gorm:"not null"`
     SubmittedBy      uint           `gorm:"not null"`
-    SubmissionWindow uint           `gorm:"not null;index"`
+    SubmissionWindow uint           `gorm:"not null;uniqueIndex:idx_phrase_window,where:deleted_at IS NULL"` // One phrase per window
+    BankPhraseID     *uint          // Set when picked from the phrase bank, SubmittedBy is then 0
     CreatedAt        time.Time
     UpdatedAt        time.Time
     DeletedAt        gorm.DeletedAt `gorm:"index"`
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Kinds of schedule override
+const (
+	OverrideSkip      = "skip"       // No window on the matching days
+	OverrideHoliday   = "holiday"    // A named skip, listed in the holiday calendar
+	OverrideFixedTime = "fixed_time" // The window opens at Time on the matching days
+	OverrideRange     = "range"      // The window opens between OpenStart and OpenEnd
+)
+
+// Recurrences of a schedule override
+const (
+	RecurNone   = ""
+	RecurWeekly = "weekly"
+	RecurYearly = "yearly"
+)
+
+// ScheduleOverride is a rule the scheduler consults when planning windows,
+// changing what the game's schedule would do on the days it matches
+type ScheduleOverride struct {
+	ID         uint   `gorm:"primaryKey"`
+	GameID     uint   `gorm:"not null;index"`
+	Kind       string `gorm:"not null"`
+	Name       string // Shown in the holiday calendar, required for holidays
+	StartDate  string `gorm:"not null"` // YYYY-MM-DD local date, inclusive
+	EndDate    string `gorm:"not null"` // YYYY-MM-DD local date, inclusive
+	Recurrence string // Empty for once, weekly or yearly
+	Until      string // YYYY-MM-DD last date a recurring rule applies, empty for forever
+	Time       string // HH:MM local time, for fixed_time
+	OpenStart  string // HH:MM local time, for range
+	OpenEnd    string // HH:MM local time, for range
+	CreatedBy  uint   `gorm:"not null"`
+	CreatedAt  time.Time
+}
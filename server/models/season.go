@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reasons a season ended
+const (
+	SeasonEndWinner      = "winner"
+	SeasonEndNoSurvivors = "no_survivors"
+	SeasonEndExpired     = "expired"
+	SeasonEndAdmin       = "admin"
+)
+
+type Season struct {
+	ID        uint       `gorm:"primaryKey"`
+	GameID    uint       `gorm:"not null;index"`
+	Name      string     `gorm:"not null"`
+	StartedAt time.Time  `gorm:"not null"`
+	EndsAt    *time.Time // Planned end, the season runs until a winner emerges when nil
+	EndedAt   *time.Time `gorm:"index"`
+	EndReason string
+	WinnerID  *uint
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// SeasonResult archives a member's final standing in an ended season
+type SeasonResult struct {
+	ID                    uint `gorm:"primaryKey"`
+	SeasonID              uint `gorm:"not null;index"`
+	UserID                uint `gorm:"not null"`
+	Rank                  int  `gorm:"not null"`
+	IsEliminated          bool `gorm:"not null"`
+	EliminatedAt          *time.Time
+	VerificationsReceived int64 `gorm:"not null"`
+	VerificationsGiven    int64 `gorm:"not null"`
+	PhrasesSubmitted      int64 `gorm:"not null"`
+	CreatedAt             time.Time
+}
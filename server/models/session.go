@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// Session is a signed-in device. Its refresh token is rotated on every use,
+// and only hashes of the current and previous tokens are stored.
+type Session struct {
+	ID                uint   `gorm:"primaryKey"`
+	UserID            uint   `gorm:"not null;index"`
+	RefreshTokenHash  string `gorm:"not null;uniqueIndex"`
+	PreviousTokenHash string `gorm:"index"` // Presenting this again means the token was stolen
+	UserAgent         string
+	IPAddress         string
+	ExpiresAt         time.Time `gorm:"not null;index"`
+	LastUsedAt        time.Time
+	RevokedAt         *time.Time `gorm:"index"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
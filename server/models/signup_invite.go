@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SignupInvite lets someone create an account when registration is invite
+// only. It is unrelated to a game's invite code, which only admits existing
+// users to that game.
+type SignupInvite struct {
+	ID        uint   `gorm:"primaryKey"`
+	Code      string `gorm:"uniqueIndex;not null"`
+	CreatedBy uint   `gorm:"not null"`
+	Note      string
+	MaxUses   int        `gorm:"not null"` // 0 for unlimited
+	Uses      int        `gorm:"not null;default:0"`
+	ExpiresAt *time.Time // Never expires when nil
+	Privilege int        `gorm:"not null;default:0"` // Granted to accounts created with the invite
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
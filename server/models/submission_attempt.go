@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// SubmissionAttempt is a phrase submission that lost the race for its window
+type SubmissionAttempt struct {
+	ID               uint      `gorm:"primaryKey"`
+	GameID           uint      `gorm:"not null;index"`
+	SubmissionWindow uint      `gorm:"not null;index"`
+	UserID           uint      `gorm:"not null"`
+	Content          string    `gorm:"not null"`
+	AttemptedAt      time.Time `gorm:"not null"` // When the server received it
+	BeatenByMs       int64     `gorm:"not null"` // How long after the winning phrase it arrived
+	CreatedAt        time.Time
+}
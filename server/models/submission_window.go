@@ -6,10 +6,35 @@ import (
 	"gorm.io/gorm"
 )
 
+// Phases a window moves through, in order. A window nobody submits a phrase
+// for goes straight from open to closed.
+const (
+    WindowScheduled = "scheduled" // Waiting for its open time
+    WindowOpen      = "open"      // Taking a phrase
+    WindowLocked    = "locked"    // Phrase chosen, admins may still correct it
+    WindowVerifying = "verifying" // Players report hearing the phrase
+    WindowClosed    = "closed"    // Waiting for the elimination pass
+    WindowScored    = "scored"
+)
+
 type SubmissionWindow struct {
-    ID        uint           `gorm:"primaryKey"`
-    OpenTime  time.Time      `gorm:"not null;index"`
-    CreatedAt time.Time
-    UpdatedAt time.Time
-    DeletedAt gorm.DeletedAt `gorm:"index"`
+    ID          uint           `gorm:"primaryKey"`
+    GameID      uint           `gorm:"not null;default:0;index"`
+    SeasonID    uint           `gorm:"not null;default:0;index"` // 0 when no season was running
+    Phase       string         `gorm:"not null;default:'scheduled';index"`
+    OpenTime    time.Time      `gorm:"not null;index"`
+    RangeStart  *time.Time     // Span the open time was drawn from
+    RangeEnd    *time.Time
+    Commitment  string         // SHA-256 commitment to Seed, published in advance
+    Seed        string         `json:"-"` // Secret until the window opens; empty when an admin picked the time
+    Hidden      bool           `gorm:"not null;default:false"` // Open time not shown to anyone before it opens
+    CloseTime   time.Time      `gorm:"index"` // When the verification period ends
+    OpenedAt    *time.Time
+    LockedAt    *time.Time     // When the phrase was claimed
+    VerifyingAt *time.Time
+    ClosedAt    *time.Time
+    ScoredAt    *time.Time     `gorm:"index"` // Set once the elimination pass has run
+    CreatedAt   time.Time
+    UpdatedAt   time.Time
+    DeletedAt   gorm.DeletedAt `gorm:"index"`
 }
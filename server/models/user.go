@@ -11,9 +11,10 @@ type User struct {
 	Username     string `gorm:"unique;not null"`
 	PasswordHash string `gorm:"not null"`
 	// Email        string         `gorm:"unique;not null"`
-	Privilege    int  `gorm:"not null;default:0"` // 0: Normal, 1: Admin, 2: Super Admin
-	IsEliminated bool `gorm:"not null;default:false"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	Privilege   int    `gorm:"not null;default:0"` // 0: Normal, 1: Admin, 2: Super Admin
+	DisplayName string // Shown instead of the username when set
+	Preferences string `gorm:"not null;default:'{}'"` // Client settings as a JSON object
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
 }
@@ -6,12 +6,47 @@ import (
 	"gorm.io/gorm"
 )
 
+// Verification states. A verification only counts once confirmed.
+const (
+	VerificationPending   = "pending"
+	VerificationConfirmed = "confirmed"
+	VerificationDisputed  = "disputed"
+	VerificationRejected  = "rejected"
+)
+
 type Verification struct {
     ID                uint           `gorm:"primaryKey"`
-    VerifiedUserID    uint           `gorm:"not null"`
+    GameID            uint           `gorm:"not null;default:0;index"`
+    VerifiedUserID    uint           `gorm:"not null;uniqueIndex:idx_verification_window,where:status <> 'rejected' AND deleted_at IS NULL"` // One standing verification per player per window
     VerifierID        uint           `gorm:"not null"`
-    SubmissionWindow  uint           `gorm:"not null;index"`
+    SubmissionWindow  uint           `gorm:"not null;index;uniqueIndex:idx_verification_window"`
+    Status            string         `gorm:"not null;default:'confirmed';index"`
+    ContextNote       string         // Where and how the phrase was heard
+    HeardAt           *time.Time     // When the phrase was heard, if different from when it was reported
     CreatedAt         time.Time
     UpdatedAt         time.Time
     DeletedAt         gorm.DeletedAt `gorm:"index"`
 }
+
+// VerificationAttachment is an image or audio clip backing up a verification.
+// The file lives in the upload directory under StoredName.
+type VerificationAttachment struct {
+    ID             uint           `gorm:"primaryKey"`
+    VerificationID uint           `gorm:"not null;index"`
+    UploadedBy     uint           `gorm:"not null"`
+    FileName       string         `gorm:"not null"` // Name as uploaded
+    StoredName     string         `gorm:"not null;unique"`
+    MimeType       string         `gorm:"not null"`
+    Size           int64          `gorm:"not null"`
+    CreatedAt      time.Time
+    DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+// VerificationConfirmation records a member vouching for a verification. The
+// original verifier's claim counts as the first confirmation.
+type VerificationConfirmation struct {
+    ID             uint      `gorm:"primaryKey"`
+    VerificationID uint      `gorm:"not null;uniqueIndex:idx_confirmation"`
+    UserID         uint      `gorm:"not null;uniqueIndex:idx_confirmation"`
+    CreatedAt      time.Time
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/bluefalconhd/lbd_game/server/config"
 	"github.com/bluefalconhd/lbd_game/server/controllers"
 	"github.com/bluefalconhd/lbd_game/server/middleware"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/bluefalconhd/lbd_game/server/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -26,33 +28,146 @@ func SetupRouter(cfg config.Config) *gin.Engine {
 	router.Use(cors.New(config))
 
 	// Public routes
+	router.GET("/registration", controllers.RegistrationInfo)
 	router.POST("/signup", controllers.SignUp)
-	router.POST("/login", controllers.Login)
-	router.GET("/phrase", controllers.GetCurrentPhrase)
+	// Failed logins back off per username, and more leniently per IP since
+	// players can share an address
+	loginByIP := utils.NewLoginThrottle(utils.NewMemoryAttemptStore(24*time.Hour), utils.ThrottlePolicy{
+		FreeFailures: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     cfg.LoginLockout,
+		MaxFailures:  cfg.LoginMaxFailures * 5,
+		Lockout:      cfg.LoginLockout,
+	})
+	loginByUsername := utils.NewLoginThrottle(utils.NewMemoryAttemptStore(24*time.Hour), utils.ThrottlePolicy{
+		FreeFailures: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     cfg.LoginLockout,
+		MaxFailures:  cfg.LoginMaxFailures,
+		Lockout:      cfg.LoginLockout,
+	})
+	router.POST("/login", middleware.LoginThrottleMiddleware(loginByIP, loginByUsername), controllers.Login)
+	router.POST("/refresh", controllers.RefreshToken)
+	router.GET("/.well-known/jwks.json", controllers.JWKS)
+	router.POST("/password/reset", middleware.RateLimitMiddleware(utils.NewRateLimiter(10, 15*time.Minute)), controllers.ResetPassword)
+	router.GET("/phrase", middleware.DefaultGameMiddleware(), controllers.GetCurrentPhrase)
 
 	// Protected routes
 	protected := router.Group("/")
 	protected.Use(middleware.AuthMiddleware())
 	{
 		protected.GET("/privilege", controllers.Privilege)
-		protected.POST("/phrase", controllers.SubmitPhrase)
-		protected.GET("/can_submit_phrase", controllers.CanSubmitPhrase)
-		protected.POST("/verify", controllers.VerifyUser)
-		protected.GET("/verifications", controllers.GetCurrentVerifications)
-		protected.GET("/unverified_users", controllers.GetUnverifiedUsers)
+		protected.POST("/logout", controllers.Logout)
+		protected.POST("/logout/all", controllers.LogoutAll)
+		protected.GET("/me", controllers.GetMe)
+		protected.PATCH("/me", controllers.UpdateMe)
+		protected.PUT("/me/password", controllers.ChangePassword)
+		protected.GET("/me/sessions", controllers.GetSessions)
+		protected.DELETE("/me/sessions/:id", controllers.RevokeSession)
+		protected.GET("/me/eliminations", controllers.GetMyEliminations)
+		protected.GET("/games", controllers.GetMyGames)
+		protected.POST("/games", controllers.CreateGame)
+		protected.POST("/games/join", controllers.JoinGame)
+	}
+
+	// Routes from before games existed, kept for clients that don't know about
+	// games yet. They act on the default game.
+	legacy := protected.Group("")
+	legacy.Use(middleware.DefaultGameMiddleware(), middleware.GameMemberMiddleware())
+	{
+		legacy.GET("/can_submit_phrase", controllers.CanSubmitPhrase)
+		legacy.GET("/verifications", controllers.GetCurrentVerifications)
+		legacy.GET("/unverified_users", controllers.GetUnverifiedUsers)
+		legacy.POST("/phrase", middleware.ActivePlayerMiddleware(), controllers.SubmitPhrase)
+		legacy.POST("/verify", middleware.ActivePlayerMiddleware(), controllers.VerifyUser)
+	}
+
+	// Game routes, scoped to members of the game
+	game := protected.Group("/games/:gameID")
+	game.Use(middleware.GameMemberMiddleware())
+	{
+		game.GET("", controllers.GetGame)
+		game.DELETE("/membership", controllers.LeaveGame)
+		game.GET("/events", controllers.Events)
+		game.GET("/window", controllers.GetCurrentWindow)
+		game.GET("/windows/:id/audit", controllers.GetWindowAudit)
+		game.GET("/phrase", controllers.GetCurrentPhrase)
+		game.GET("/can_submit_phrase", controllers.CanSubmitPhrase)
+		game.GET("/phrases", controllers.GetPhraseArchive)
+		game.GET("/verifications", controllers.GetCurrentVerifications)
+		game.POST("/verifications/:id/dispute", controllers.DisputeVerification)
+		game.GET("/verifications/:id/attachments/:attachmentID", controllers.GetVerificationAttachment)
+		game.GET("/unverified_users", controllers.GetUnverifiedUsers)
+		game.GET("/me/history", controllers.GetMyHistory)
+		game.GET("/users/:id/stats", controllers.GetUserStats)
+		game.GET("/leaderboard", controllers.GetLeaderboard)
+		game.GET("/seasons", controllers.GetSeasons)
+		game.GET("/seasons/current/standings", controllers.GetCurrentStandings)
+		game.GET("/seasons/:seasonID", controllers.GetSeason)
+	}
+
+	// Game actions that eliminated players lose
+	player := game.Group("")
+	player.Use(middleware.ActivePlayerMiddleware())
+	{
+		player.POST("/phrase", controllers.SubmitPhrase)
+		player.POST("/verify", controllers.VerifyUser)
+		player.POST("/verifications/:id/confirm", controllers.ConfirmVerification)
+		player.POST("/verifications/:id/attachments", controllers.AddVerificationAttachments)
+		player.POST("/phrase_bank", controllers.SuggestBankPhrase)
+	}
+
+	// Game admin routes
+	gameAdmin := game.Group("/admin")
+	gameAdmin.Use(middleware.GameRoleMiddleware(models.RoleAdmin))
+	{
+		gameAdmin.PATCH("", controllers.UpdateGame)
+		gameAdmin.POST("/invite_code", controllers.RegenerateInviteCode)
+		gameAdmin.GET("/stats/users", controllers.GetUserStatistics)
+		gameAdmin.PUT("/user/:id/resurrect", controllers.ResurrectUser)
+		gameAdmin.GET("/eliminations", controllers.GetEliminations)
+		gameAdmin.GET("/disputes", controllers.GetDisputes)
+		gameAdmin.PUT("/disputes/:id/resolve", controllers.ResolveDispute)
+		gameAdmin.PUT("/edit_phrase", controllers.EditPhrase)
+		gameAdmin.PUT("/unsubmit_phrase", controllers.UnsubmitPhrase)
+		gameAdmin.GET("/banned_words", controllers.GetBannedWords)
+		gameAdmin.POST("/banned_words", controllers.AddBannedWords)
+		gameAdmin.DELETE("/banned_words/:id", controllers.RemoveBannedWord)
+		gameAdmin.GET("/phrase_bank", controllers.GetBankPhrases)
+		gameAdmin.POST("/phrase_bank", controllers.AddBankPhrase)
+		gameAdmin.PUT("/phrase_bank/:id/review", controllers.ReviewBankPhrase)
+		gameAdmin.DELETE("/phrase_bank/:id", controllers.RemoveBankPhrase)
+		gameAdmin.GET("/scheduled_windows", controllers.GetScheduledWindows)
+		gameAdmin.DELETE("/scheduled_windows/:id", controllers.CancelScheduledWindow)
+		gameAdmin.PUT("/manual_reset", controllers.ManualReset)
+		gameAdmin.GET("/schedule", controllers.GetSchedule)
+		gameAdmin.PUT("/schedule", controllers.UpdateSchedule)
+		gameAdmin.GET("/schedule/preview", controllers.PreviewSchedule)
+		gameAdmin.GET("/schedule/overrides", controllers.GetScheduleOverrides)
+		gameAdmin.POST("/schedule/overrides", controllers.CreateScheduleOverride)
+		gameAdmin.DELETE("/schedule/overrides/:id", controllers.DeleteScheduleOverride)
+		gameAdmin.POST("/seasons", controllers.StartSeason)
+		gameAdmin.POST("/seasons/current/end", controllers.EndCurrentSeason)
+	}
+
+	// Game owner routes
+	gameOwner := game.Group("/owner")
+	gameOwner.Use(middleware.GameRoleMiddleware(models.RoleOwner))
+	{
+		gameOwner.PUT("/user/:id/role", controllers.SetMemberRole)
 	}
 
 	// Admin routes
 	admin := protected.Group("/admin")
 	admin.Use(middleware.PrivilegeMiddleware(1)) // Requires at least Admin Level 1
 	{
-		admin.GET("/stats/users", controllers.GetUserStatistics)
-		// admin.PUT("/user/:id/resurrect", controllers.ResurrectUser)
-		admin.PUT("/edit_phrase", controllers.EditPhrase)
-		admin.PUT("/unsubmit_phrase", controllers.UnsubmitPhrase)
-		admin.GET("/scheduled_windows", controllers.GetScheduledWindows)
-		admin.DELETE("/scheduled_windows/:id", controllers.CancelScheduledWindow)
-		admin.PUT("/manual_reset", controllers.ManualReset)
+		admin.GET("/games", controllers.GetAllGames)
+		admin.POST("/users/:id/reset_code", controllers.IssueResetCode)
+		admin.GET("/invites", controllers.GetSignupInvites)
+		admin.POST("/invites", controllers.CreateSignupInvite)
+		admin.DELETE("/invites/:id", controllers.RevokeSignupInvite)
+		admin.GET("/failed_logins", controllers.GetFailedLogins)
+		admin.GET("/failed_logins/summary", controllers.GetFailedLoginSummary)
 	}
 
 	// Super Admin routes
@@ -63,5 +178,16 @@ func SetupRouter(cfg config.Config) *gin.Engine {
 		superAdmin.PUT("/user/:id/demote", controllers.DemoteUser)
 	}
 
+	// Time travel for playing through a game in development
+	if cfg.SimulatedClock {
+		clock := superAdmin.Group("/clock")
+		{
+			clock.GET("", controllers.GetClock)
+			clock.POST("/freeze", controllers.FreezeClock)
+			clock.POST("/resume", controllers.ResumeClock)
+			clock.POST("/advance", controllers.AdvanceClock)
+		}
+	}
+
 	return router
 }
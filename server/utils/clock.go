@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/config"
+)
+
+// Clock tells the game what time it is. Windows, phrases, verifications and
+// scoring ask it rather than time.Now, so a simulated clock can play through
+// days of a game in seconds. Logins, sessions and tokens stay on real time.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// SimClock starts at real time and can then be frozen and moved forward. It
+// never goes back, so the job ledger never sees a run from the future.
+type SimClock struct {
+	mu     sync.Mutex
+	offset time.Duration
+	frozen *time.Time
+}
+
+func (s *SimClock) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now()
+}
+
+func (s *SimClock) now() time.Time {
+	if s.frozen != nil {
+		return *s.frozen
+	}
+	return time.Now().Add(s.offset)
+}
+
+// Frozen reports whether the clock is stopped
+func (s *SimClock) Frozen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.frozen != nil
+}
+
+// Freeze stops the clock at the current simulated time
+func (s *SimClock) Freeze() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.frozen = &now
+	return now
+}
+
+// Resume lets a frozen clock run again from where it stopped
+func (s *SimClock) Resume() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frozen != nil {
+		s.offset = time.Until(*s.frozen)
+		s.frozen = nil
+	}
+	return s.now()
+}
+
+// Advance moves the clock forward by d
+func (s *SimClock) Advance(d time.Duration) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frozen != nil {
+		moved := s.frozen.Add(d)
+		s.frozen = &moved
+	} else {
+		s.offset += d
+	}
+	return s.now()
+}
+
+var clock Clock = realClock{}
+
+// Now is the current game time in UTC
+func Now() time.Time {
+	return clock.Now().UTC()
+}
+
+// InitClock switches to a simulated clock when configured. Rows keep their
+// real time stamps, so game records set the times that matter explicitly.
+func InitClock(cfg config.Config) {
+	if cfg.SimulatedClock {
+		fmt.Println("Using a simulated clock; don't run this in production")
+		clock = &SimClock{}
+	}
+}
+
+// SimulatedClock returns the simulated clock, or nil when on real time
+func SimulatedClock() *SimClock {
+	sim, _ := clock.(*SimClock)
+	return sim
+}
+
+// Game time advances in steps this long, so every job sees the skipped time
+const clockTick = 15 * time.Minute
+
+// AdvanceClock moves the simulated clock forward a step at a time, running
+// every game time job at each step as if the time had passed for real
+func AdvanceClock(sim *SimClock, d time.Duration) time.Time {
+	for remaining := d; remaining > 0; remaining -= clockTick {
+		now := sim.Advance(min(remaining, clockTick)).UTC()
+		for _, job := range jobs {
+			if !job.RealTime {
+				RunJob(job, now, remaining > clockTick)
+			}
+		}
+	}
+	return Now()
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useTestDB points database.DB at a fresh database for the test, set up the
+// way ConnectDatabase sets up the real one
+func useTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+		Logger:  logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Game{}, &models.GameMember{}, &models.SubmissionWindow{}, &models.Phrase{},
+		&models.SubmissionAttempt{}, &models.Season{}, &models.GameSchedule{}, &models.ScheduleOverride{},
+		&models.User{}, &models.SignupInvite{}, &models.Verification{}, &models.SeasonResult{}, &models.Elimination{},
+		&models.JobRun{}, &models.Session{}, &models.PasswordReset{}); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+var testGames, testUsers int
+
+// createTestGame stores a game with a unique invite code
+func createTestGame(t *testing.T) *models.Game {
+	t.Helper()
+
+	testGames++
+	game := models.Game{Name: "test", InviteCode: fmt.Sprintf("TEST%d", testGames)}
+	if err := database.DB.Create(&game).Error; err != nil {
+		t.Fatalf("creating game: %v", err)
+	}
+	return &game
+}
+
+// createTestMembers stores n users and adds them to the game as players
+func createTestMembers(t *testing.T, gameID uint, n int) []uint {
+	t.Helper()
+
+	ids := make([]uint, n)
+	for i := range ids {
+		testUsers++
+		user := models.User{Username: fmt.Sprintf("player%d", testUsers), PasswordHash: "hash"}
+		if err := database.DB.Create(&user).Error; err != nil {
+			t.Fatalf("creating user: %v", err)
+		}
+		if err := database.DB.Create(&models.GameMember{GameID: gameID, UserID: user.ID}).Error; err != nil {
+			t.Fatalf("creating member: %v", err)
+		}
+		ids[i] = user.ID
+	}
+	return ids
+}
+
+// createTestWindow stores a game and a window in the given phase opening at
+// openTime and closing ten hours later
+func createTestWindow(t *testing.T, phase string, openTime time.Time) *models.SubmissionWindow {
+	t.Helper()
+
+	window := models.SubmissionWindow{
+		GameID:    createTestGame(t).ID,
+		Phase:     phase,
+		OpenTime:  openTime.UTC(),
+		CloseTime: openTime.Add(10 * time.Hour).UTC(),
+	}
+	if err := database.DB.Create(&window).Error; err != nil {
+		t.Fatalf("creating window: %v", err)
+	}
+	return &window
+}
@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/config"
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// RuleNotVerified eliminates players nobody verified during the window
+	RuleNotVerified = "not_verified"
+	// RuleVerifiedNobody eliminates players who verified nobody during the window
+	RuleVerifiedNobody = "verified_nobody"
+)
+
+var eliminationRules = []string{RuleNotVerified}
+
+// ValidateEliminationRules rejects rules the elimination pass doesn't know
+func ValidateEliminationRules(rules []string) error {
+	for _, rule := range rules {
+		if rule != RuleNotVerified && rule != RuleVerifiedNobody {
+			return fmt.Errorf("unknown elimination rule %q", rule)
+		}
+	}
+	return nil
+}
+
+// SetEliminationRules configures the rules applied to games that don't set
+// their own. Rules are evaluated in order and the first matching rule is
+// recorded as the elimination reason.
+func SetEliminationRules(rules []string) error {
+	if err := ValidateEliminationRules(rules); err != nil {
+		return err
+	}
+	eliminationRules = rules
+	return nil
+}
+
+// GameEliminationRules returns the rules applied to a game
+func GameEliminationRules(game *models.Game) []string {
+	if game.EliminationRules == "" {
+		return eliminationRules
+	}
+	return config.SplitList(game.EliminationRules)
+}
+
+// RunEliminationPass scores every window that has closed since the last pass.
+func RunEliminationPass(now time.Time) error {
+	now = now.UTC()
+
+	var windows []models.SubmissionWindow
+	if err := database.DB.Where("phase = ?", models.WindowClosed).
+		Order("open_time asc").
+		Find(&windows).Error; err != nil {
+		return fmt.Errorf("fetching unscored windows: %w", err)
+	}
+
+	// Windows are scored in order, so once one can't be scored yet the
+	// game's later windows wait too
+	running := make(map[uint]bool)
+
+	for _, window := range windows {
+		if running[window.GameID] {
+			continue
+		}
+
+		// Wait for admins to settle disputes before deciding who survived
+		var disputed int64
+		if err := database.DB.Model(&models.Verification{}).
+			Where("submission_window = ? AND status = ?", window.ID, models.VerificationDisputed).
+			Count(&disputed).Error; err != nil || disputed > 0 {
+			running[window.GameID] = true
+			continue
+		}
+
+		var game models.Game
+		if err := database.DB.First(&game, window.GameID).Error; err != nil {
+			fmt.Printf("Failed to fetch game %d for window %d: %v\n", window.GameID, window.ID, err)
+			running[window.GameID] = true
+			continue
+		}
+
+		eliminated, err := eliminateForWindow(&game, &window, now)
+		if err != nil {
+			fmt.Printf("Failed to run elimination for window %d: %v\n", window.ID, err)
+			running[window.GameID] = true
+			continue
+		}
+
+		if len(eliminated) > 0 {
+			fmt.Printf("Eliminated %d users in game %d for window %d\n", len(eliminated), game.ID, window.ID)
+			TriggerUpdate(game.ID, UpdateElimination)
+		}
+	}
+
+	CheckSeasonEnds(now)
+	return nil
+}
+
+// eliminationsApply reports whether a window counts towards eliminations.
+// Games that have never run a season eliminate all the time; otherwise only
+// windows of a running season count.
+func eliminationsApply(tx *gorm.DB, window *models.SubmissionWindow) (bool, error) {
+	if window.SeasonID != 0 {
+		var running int64
+		err := tx.Model(&models.Season{}).Where("id = ? AND ended_at IS NULL", window.SeasonID).Count(&running).Error
+		return running > 0, err
+	}
+
+	var seasons int64
+	err := tx.Model(&models.Season{}).Where("game_id = ?", window.GameID).Count(&seasons).Error
+	return seasons == 0, err
+}
+
+// eliminateForWindow applies the game's elimination rules to a closed window
+// and marks it as scored. It returns the IDs of the users that were
+// eliminated.
+func eliminateForWindow(game *models.Game, window *models.SubmissionWindow, now time.Time) ([]uint, error) {
+	var eliminated []uint
+	rules := GameEliminationRules(game)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Nobody can be expected to say a phrase that was never submitted
+		var phraseCount int64
+		if err := tx.Model(&models.Phrase{}).Where("submission_window = ?", window.ID).Count(&phraseCount).Error; err != nil {
+			return err
+		}
+
+		apply, err := eliminationsApply(tx, window)
+		if err != nil {
+			return err
+		}
+
+		if phraseCount > 0 && apply {
+			reasons, err := matchEliminationRules(tx, rules, window)
+			if err != nil {
+				return err
+			}
+
+			for _, rule := range rules {
+				for _, userID := range reasons[rule] {
+					result := tx.Model(&models.GameMember{}).
+						Where("game_id = ? AND user_id = ? AND is_eliminated = ?", game.ID, userID, false).
+						Updates(map[string]interface{}{
+							"is_eliminated":      true,
+							"eliminated_at":      now,
+							"elimination_reason": rule,
+						})
+					if result.Error != nil {
+						return result.Error
+					}
+					if result.RowsAffected == 0 {
+						continue
+					}
+
+					record := models.Elimination{
+						GameID:           game.ID,
+						UserID:           userID,
+						SubmissionWindow: window.ID,
+						Reason:           rule,
+						CreatedAt:        now,
+					}
+					if err := tx.Create(&record).Error; err != nil {
+						return err
+					}
+
+					eliminated = append(eliminated, userID)
+				}
+			}
+		}
+
+		return tx.Model(window).Updates(map[string]interface{}{"phase": models.WindowScored, "scored_at": now}).Error
+	})
+
+	return eliminated, err
+}
+
+// matchEliminationRules returns, for each rule, the active members that broke
+// it during the window. Members who joined after the window opened are not
+// held to it.
+func matchEliminationRules(tx *gorm.DB, rules []string, window *models.SubmissionWindow) (map[string][]uint, error) {
+	reasons := make(map[string][]uint)
+
+	for _, rule := range rules {
+		var column string
+		switch rule {
+		case RuleNotVerified:
+			column = "verified_user_id"
+		case RuleVerifiedNobody:
+			column = "verifier_id"
+		default:
+			continue
+		}
+
+		var userIDs []uint
+		if err := tx.Model(&models.GameMember{}).
+			Where("game_id = ? AND is_eliminated = ? AND created_at < ?", window.GameID, false, window.OpenTime).
+			Where("user_id NOT IN (?)",
+				tx.Table("verifications").
+					Select(column).
+					Where("submission_window = ? AND status = ? AND deleted_at IS NULL", window.ID, models.VerificationConfirmed)).
+			Pluck("user_id", &userIDs).Error; err != nil {
+			return nil, err
+		}
+
+		reasons[rule] = userIDs
+	}
+
+	return reasons, nil
+}
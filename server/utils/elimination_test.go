@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+func TestRunEliminationPass(t *testing.T) {
+	useTestDB(t)
+
+	type verification struct {
+		verifier, verified int // Indexes into the game's members
+		status             string
+	}
+
+	tests := []struct {
+		name          string
+		rules         string // Game's rules, empty for the server default
+		noPhrase      bool
+		endedSeason   bool
+		verifications []verification
+		lateJoiner    bool           // Last member joined after the window opened
+		want          map[int]string // Eliminated members and why
+		scored        bool
+	}{
+		{
+			name:          "unverified players go out",
+			verifications: []verification{{1, 0, models.VerificationConfirmed}},
+			want:          map[int]string{1: RuleNotVerified, 2: RuleNotVerified, 3: RuleNotVerified},
+			scored:        true,
+		},
+		{
+			name:          "pending verifications don't count",
+			verifications: []verification{{1, 0, models.VerificationPending}},
+			want:          map[int]string{0: RuleNotVerified, 1: RuleNotVerified, 2: RuleNotVerified, 3: RuleNotVerified},
+			scored:        true,
+		},
+		{
+			name:          "first matching rule is the reason",
+			rules:         RuleVerifiedNobody + "," + RuleNotVerified,
+			verifications: []verification{{1, 0, models.VerificationConfirmed}},
+			want:          map[int]string{0: RuleVerifiedNobody, 1: RuleNotVerified, 2: RuleVerifiedNobody, 3: RuleVerifiedNobody},
+			scored:        true,
+		},
+		{
+			name:          "late joiners aren't held to the window",
+			verifications: []verification{{1, 0, models.VerificationConfirmed}},
+			lateJoiner:    true,
+			want:          map[int]string{1: RuleNotVerified, 2: RuleNotVerified},
+			scored:        true,
+		},
+		{
+			name:     "no phrase, nobody goes out",
+			noPhrase: true,
+			want:     map[int]string{},
+			scored:   true,
+		},
+		{
+			name:        "windows of an ended season don't count",
+			endedSeason: true,
+			want:        map[int]string{},
+			scored:      true,
+		},
+		{
+			name:          "disputes hold up scoring",
+			verifications: []verification{{1, 0, models.VerificationDisputed}},
+			want:          map[int]string{},
+			scored:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTime := Now().Add(time.Second)
+			window := createTestWindow(t, models.WindowClosed, openTime)
+			database.DB.Model(&models.Game{}).Where("id = ?", window.GameID).Update("elimination_rules", tt.rules)
+
+			members := createTestMembers(t, window.GameID, 4)
+			if tt.lateJoiner {
+				database.DB.Model(&models.GameMember{}).Where("user_id = ?", members[3]).Update("created_at", openTime.Add(time.Minute))
+			}
+
+			if tt.endedSeason {
+				ended := Now()
+				season := models.Season{GameID: window.GameID, Name: "over", StartedAt: ended.Add(-time.Hour), EndedAt: &ended}
+				if err := database.DB.Create(&season).Error; err != nil {
+					t.Fatalf("creating season: %v", err)
+				}
+				database.DB.Model(window).Update("season_id", season.ID)
+			}
+
+			if !tt.noPhrase {
+				if err := database.DB.Create(&models.Phrase{
+					GameID: window.GameID, Content: "phrase", SubmittedBy: members[0], SubmissionWindow: window.ID, CreatedAt: openTime,
+				}).Error; err != nil {
+					t.Fatalf("creating phrase: %v", err)
+				}
+			}
+
+			for _, v := range tt.verifications {
+				if err := database.DB.Create(&models.Verification{
+					GameID: window.GameID, VerifierID: members[v.verifier], VerifiedUserID: members[v.verified],
+					SubmissionWindow: window.ID, Status: v.status,
+				}).Error; err != nil {
+					t.Fatalf("creating verification: %v", err)
+				}
+			}
+
+			if err := RunEliminationPass(openTime.Add(11 * time.Hour)); err != nil {
+				t.Fatalf("RunEliminationPass: %v", err)
+			}
+
+			for i, userID := range members {
+				member, err := GetMembership(window.GameID, userID)
+				if err != nil {
+					t.Fatalf("GetMembership: %v", err)
+				}
+				reason, out := tt.want[i]
+				if member.IsEliminated != out || member.EliminationReason != reason {
+					t.Errorf("member %d eliminated = %v (%q), want %v (%q)", i, member.IsEliminated, member.EliminationReason, out, reason)
+				}
+			}
+
+			var records int64
+			database.DB.Model(&models.Elimination{}).Where("submission_window = ?", window.ID).Count(&records)
+			if records != int64(len(tt.want)) {
+				t.Errorf("recorded %d eliminations, want %d", records, len(tt.want))
+			}
+
+			var stored models.SubmissionWindow
+			database.DB.First(&stored, window.ID)
+			if scored := stored.Phase == models.WindowScored; scored != tt.scored {
+				t.Errorf("window phase = %s, want scored %v", stored.Phase, tt.scored)
+			}
+		})
+	}
+}
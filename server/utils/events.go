@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"sync"
+)
+
+// Event names understood by the client
+const (
+	EventTriggerUpdate = "trigger_update"
+)
+
+// Update types carried by trigger_update events
+const (
+	UpdatePhrase       = "phrase"
+	UpdateVerification = "verification"
+	UpdateWindow       = "window"
+	UpdateElimination  = "elimination"
+	UpdateMembers      = "members"
+	UpdateSeason       = "season"
+)
+
+// Event mirrors the client's WebSocketMessage shape
+type Event struct {
+	GameID uint        `json:"-"`
+	Event  string      `json:"event"`
+	Data   interface{} `json:"data"`
+}
+
+type UpdateTriggerData struct {
+	Type string `json:"type"`
+}
+
+// EventHub fans events out to the subscribers of each game
+type EventHub struct {
+	mu          sync.RWMutex
+	subscribers map[chan Event]uint
+}
+
+var Events = NewEventHub()
+
+func NewEventHub() *EventHub {
+	return &EventHub{subscribers: make(map[chan Event]uint)}
+}
+
+func (h *EventHub) Subscribe(gameID uint) chan Event {
+	ch := make(chan Event, 16)
+
+	h.mu.Lock()
+	h.subscribers[ch] = gameID
+	h.mu.Unlock()
+
+	return ch
+}
+
+func (h *EventHub) Unsubscribe(ch chan Event) {
+	h.mu.Lock()
+	if _, ok := h.subscribers[ch]; ok {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+	h.mu.Unlock()
+}
+
+// Publish delivers the event to every subscriber of its game without
+// blocking. Slow subscribers miss events rather than stalling the publisher.
+func (h *EventHub) Publish(event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch, gameID := range h.subscribers {
+		if gameID != event.GameID {
+			continue
+		}
+
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// TriggerUpdate tells a game's clients to refetch the given kind of state
+func TriggerUpdate(gameID uint, updateType string) {
+	Events.Publish(Event{
+		GameID: gameID,
+		Event:  EventTriggerUpdate,
+		Data:   UpdateTriggerData{Type: updateType},
+	})
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+// Open times are drawn from a secret seed whose hash is published when the
+// window is planned. Once the window opens the seed is revealed and anyone
+// can check both that it matches the commitment and that it gives the open
+// time:
+//
+//	commitment = hex(SHA-256("commitment:" + seed))
+//	offset     = big-endian uint64 of SHA-256("open_time:" + seed)[:8] mod (range_end - range_start in seconds)
+//	open_time  = range_start + offset seconds
+//
+// The prefixes keep the published commitment from revealing the offset.
+const (
+	commitmentPrefix = "commitment:"
+	openTimePrefix   = "open_time:"
+)
+
+// newSeed returns 32 random bytes as hex
+func newSeed() (string, error) {
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(seed), nil
+}
+
+// Commitment is the published hash of a seed
+func Commitment(seed string) string {
+	sum := sha256.Sum256([]byte(commitmentPrefix + seed))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeriveOpenTime picks the open time in [start, end) that the seed commits to
+func DeriveOpenTime(seed string, start, end time.Time) time.Time {
+	seconds := int64(end.Sub(start) / time.Second)
+	if seconds < 1 {
+		return start
+	}
+	sum := sha256.Sum256([]byte(openTimePrefix + seed))
+	offset := binary.BigEndian.Uint64(sum[:8]) % uint64(seconds)
+	return start.Add(time.Duration(offset) * time.Second)
+}
+
+// PlanFairWindow builds a window whose open time in [start, end) is drawn from
+// a fresh committed seed. Games that hide open times have the window hidden.
+func PlanFairWindow(gameID uint, start, end time.Time) (models.SubmissionWindow, error) {
+	seed, err := newSeed()
+	if err != nil {
+		return models.SubmissionWindow{}, err
+	}
+
+	// Whole seconds, so the range published is exactly the one used
+	start = start.UTC().Truncate(time.Second)
+	end = end.UTC().Truncate(time.Second)
+
+	window := PlanWindow(gameID, DeriveOpenTime(seed, start, end))
+	window.Seed = seed
+	window.Commitment = Commitment(seed)
+	window.RangeStart = &start
+	window.RangeEnd = &end
+
+	database.DB.Model(&models.Game{}).Where("id = ?", gameID).Select("hide_open_times").Scan(&window.Hidden)
+
+	return window, nil
+}
+
+// SeedRevealed reports whether the window's seed may be published, which is
+// once it has opened or been cancelled
+func SeedRevealed(window *models.SubmissionWindow) bool {
+	return window.Seed != "" && (window.DeletedAt.Valid || !Now().Before(window.OpenTime))
+}
+
+// VerifyOpenTime checks the window's seed against its commitment and open
+// time. Windows an admin placed by hand have no seed and never verify.
+func VerifyOpenTime(window *models.SubmissionWindow) bool {
+	if window.Seed == "" || window.RangeStart == nil || window.RangeEnd == nil {
+		return false
+	}
+	return Commitment(window.Seed) == window.Commitment &&
+		DeriveOpenTime(window.Seed, *window.RangeStart, *window.RangeEnd).Equal(window.OpenTime)
+}
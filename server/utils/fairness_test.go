@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+func TestCommitment(t *testing.T) {
+	// sha256("commitment:" + seed), worked out independently
+	seed := strings.Repeat("a", 64)
+	want := "27ef91da43e9c249da6345c6cf503c0e36e3fd13a354171231785876b2c91441"
+	if got := Commitment(seed); got != want {
+		t.Errorf("Commitment = %s, want %s", got, want)
+	}
+}
+
+func TestDeriveOpenTime(t *testing.T) {
+	start := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	seed := strings.Repeat("a", 64)
+
+	tests := []struct {
+		name string
+		end  time.Time
+		want time.Time
+	}{
+		// The first 8 bytes of sha256("open_time:" + seed) mod 3600 are 76
+		{"hour range", start.Add(time.Hour), start.Add(76 * time.Second)},
+		{"empty range", start, start},
+		{"range under a second", start.Add(500 * time.Millisecond), start},
+		{"one second range", start.Add(time.Second), start},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeriveOpenTime(seed, start, tt.end); !got.Equal(tt.want) {
+				t.Errorf("DeriveOpenTime = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeriveOpenTimeStaysInRange(t *testing.T) {
+	start := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	end := start.Add(3*time.Hour + 50*time.Minute)
+
+	for i := 0; i < 200; i++ {
+		seed, err := newSeed()
+		if err != nil {
+			t.Fatalf("newSeed: %v", err)
+		}
+		got := DeriveOpenTime(seed, start, end)
+		if got.Before(start) || !got.Before(end) {
+			t.Fatalf("DeriveOpenTime(%s) = %s, outside [%s, %s)", seed, got, start, end)
+		}
+		if again := DeriveOpenTime(seed, start, end); !again.Equal(got) {
+			t.Fatalf("DeriveOpenTime(%s) gave %s then %s", seed, got, again)
+		}
+	}
+}
+
+func TestVerifyOpenTime(t *testing.T) {
+	seed := strings.Repeat("a", 64)
+	start := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	valid := func() models.SubmissionWindow {
+		rangeStart, rangeEnd := start, end
+		return models.SubmissionWindow{
+			OpenTime:   DeriveOpenTime(seed, start, end),
+			Seed:       seed,
+			Commitment: Commitment(seed),
+			RangeStart: &rangeStart,
+			RangeEnd:   &rangeEnd,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(*models.SubmissionWindow)
+		want   bool
+	}{
+		{"committed window", func(*models.SubmissionWindow) {}, true},
+		{"placed by hand", func(w *models.SubmissionWindow) { w.Seed, w.Commitment, w.RangeStart, w.RangeEnd = "", "", nil, nil }, false},
+		{"seed swapped", func(w *models.SubmissionWindow) { w.Seed = strings.Repeat("b", 64) }, false},
+		{"commitment swapped", func(w *models.SubmissionWindow) { w.Commitment = Commitment(strings.Repeat("b", 64)) }, false},
+		{"open time moved", func(w *models.SubmissionWindow) { w.OpenTime = w.OpenTime.Add(time.Second) }, false},
+		{"range widened", func(w *models.SubmissionWindow) { later := end.Add(time.Hour); w.RangeEnd = &later }, false},
+		{"range missing", func(w *models.SubmissionWindow) { w.RangeStart = nil }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := valid()
+			tt.tamper(&window)
+			if got := VerifyOpenTime(&window); got != tt.want {
+				t.Errorf("VerifyOpenTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm"
+)
+
+// Unambiguous characters for codes people read aloud or type by hand
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GenerateCode returns a random code of the given length
+func GenerateCode(length int) (string, error) {
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	for i, b := range buf {
+		buf[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+
+	return string(buf), nil
+}
+
+func GenerateInviteCode() (string, error) {
+	return GenerateCode(8)
+}
+
+// NormalizeCode uppercases a user supplied code and strips whitespace
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func GetMembership(gameID, userID uint) (*models.GameMember, error) {
+	var member models.GameMember
+	if err := database.DB.Where("game_id = ? AND user_id = ?", gameID, userID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// CreateGame creates a game owned by the given user and schedules its first
+// submission window.
+func CreateGame(name string, ownerID uint) (*models.Game, error) {
+	code, err := GenerateInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	game := models.Game{
+		Name:       name,
+		InviteCode: code,
+		CreatedBy:  ownerID,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&game).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.GameMember{
+			GameID:    game.ID,
+			UserID:    ownerID,
+			Role:      models.RoleOwner,
+			CreatedAt: Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scheduleGameWindow(&game, Now())
+
+	return &game, nil
+}
+
+// MigrateDefaultGame moves data from before games existed into a "Default"
+// game so existing players keep their history.
+func MigrateDefaultGame() error {
+	var gameCount, userCount int64
+	database.DB.Model(&models.Game{}).Count(&gameCount)
+	database.DB.Model(&models.User{}).Count(&userCount)
+	if gameCount > 0 || userCount == 0 {
+		return nil
+	}
+
+	var users []struct {
+		ID           uint
+		Privilege    int
+		IsEliminated bool
+	}
+
+	// is_eliminated only exists on databases created before games did
+	columns := "id, privilege"
+	if database.DB.Migrator().HasColumn(&models.User{}, "is_eliminated") {
+		columns += ", is_eliminated"
+	}
+	if err := database.DB.Table("users").Select(columns).
+		Where("deleted_at IS NULL").
+		Order("privilege desc, id asc").
+		Find(&users).Error; err != nil {
+		return err
+	}
+
+	code, err := GenerateInviteCode()
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		game := models.Game{
+			Name:       "Default",
+			InviteCode: code,
+			CreatedBy:  users[0].ID,
+		}
+		if err := tx.Create(&game).Error; err != nil {
+			return err
+		}
+
+		for i, user := range users {
+			role := models.RolePlayer
+			if i == 0 {
+				role = models.RoleOwner
+			} else if user.Privilege >= 1 {
+				role = models.RoleAdmin
+			}
+
+			if err := tx.Create(&models.GameMember{
+				GameID:       game.ID,
+				UserID:       user.ID,
+				Role:         role,
+				IsEliminated: user.IsEliminated,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, table := range []string{"submission_windows", "phrases", "verifications", "eliminations"} {
+			if err := tx.Table(table).Where("game_id = ?", 0).Update("game_id", game.ID).Error; err != nil {
+				return fmt.Errorf("failed to migrate %s: %w", table, err)
+			}
+		}
+
+		fmt.Printf("Migrated existing data into game %d\n", game.ID)
+		return nil
+	})
+}
+
+func GameExists(gameID uint) error {
+	var game models.Game
+	return database.DB.Select("id").First(&game, gameID).Error
+}
+
+// DefaultGameID returns the game that routes from before games existed act
+// on. That is the game MigrateDefaultGame created, or on servers that never
+// needed migrating, the first game anyone created.
+func DefaultGameID() (uint, error) {
+	var game models.Game
+	if err := database.DB.Select("id").Order("id asc").First(&game).Error; err != nil {
+		return 0, err
+	}
+	return game.ID, nil
+}
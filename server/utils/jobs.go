@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm/clause"
+)
+
+// Job runs replayed after downtime are capped so a long outage doesn't stall
+// startup
+const maxCatchUp = 30 * 24 * time.Hour
+
+// Ledger entries are kept this long
+const jobRunRetention = 7 * 24 * time.Hour
+
+// Job is a task the scheduler runs every Period. Run gets the time it stands
+// for, which is in the past when a missed run is replayed.
+type Job struct {
+	Name   string
+	Period time.Duration
+	// CatchUp is the step missed runs are replayed at after downtime. Zero
+	// means missed runs are dropped and the job just runs once on startup.
+	CatchUp time.Duration
+	// RealTime jobs tidy up rows stamped with real time, such as sessions,
+	// so they run on the wall clock and the simulated clock never drives them
+	RealTime bool
+	Run      func(now time.Time) error
+}
+
+// now is the time the job runs for when it runs now
+func (j *Job) now() time.Time {
+	if j.RealTime {
+		return time.Now().UTC()
+	}
+	return Now()
+}
+
+// slot is the scheduled time a run at t stands for
+func (j *Job) slot(t time.Time) time.Time {
+	return t.UTC().Truncate(j.Period)
+}
+
+// Jobs run one at a time, so a catch-up and a cron tick never interleave
+var jobsMu sync.Mutex
+
+// RunJob runs the job for the given time unless the ledger shows it already
+// ran for that slot. It reports whether the job ran.
+func RunJob(job *Job, at time.Time, replayed bool) bool {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+
+	run := models.JobRun{
+		Job:          job.Name,
+		ScheduledFor: job.slot(at),
+		Replayed:     replayed,
+		StartedAt:    time.Now().UTC(),
+	}
+
+	// Claiming the slot first means a run is never repeated, even across
+	// a crash in the middle of it
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&run)
+	if result.Error != nil {
+		fmt.Printf("Failed to record %s run: %v\n", job.Name, result.Error)
+		return false
+	}
+	if result.RowsAffected == 0 {
+		return false
+	}
+
+	err := runSafely(job, at)
+
+	updates := map[string]interface{}{"finished_at": time.Now().UTC()}
+	if err != nil {
+		fmt.Printf("Job %s failed for %s: %v\n", job.Name, run.ScheduledFor.Format(time.RFC3339), err)
+		updates["error"] = err.Error()
+	}
+	database.DB.Model(&run).Updates(updates)
+
+	return true
+}
+
+// runSafely keeps a panicking job from taking the scheduler down with it
+func runSafely(job *Job, at time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(at)
+}
+
+// CatchUpJob replays the runs a job missed since its last recorded run, in
+// order, then runs it for now. Jobs that have never run just run for now.
+func CatchUpJob(job *Job, now time.Time) {
+	var last models.JobRun
+	err := database.DB.Where("job = ?", job.Name).Order("scheduled_for desc").First(&last).Error
+
+	if err == nil && job.CatchUp > 0 {
+		from := last.ScheduledFor.Add(job.CatchUp)
+		if earliest := now.Add(-maxCatchUp); from.Before(earliest) {
+			fmt.Printf("Job %s missed runs since %s, only replaying the last %s\n",
+				job.Name, last.ScheduledFor.Format(time.RFC3339), maxCatchUp)
+			from = earliest
+		}
+
+		replayed := 0
+		for at := from; job.slot(at).Before(job.slot(now)); at = at.Add(job.CatchUp) {
+			if RunJob(job, at, true) {
+				replayed++
+			}
+		}
+		if replayed > 0 {
+			fmt.Printf("Replayed %d missed runs of %s\n", replayed, job.Name)
+		}
+	}
+
+	RunJob(job, now, false)
+}
+
+// CleanupJobRuns drops ledger entries old enough not to matter for catch-up.
+// Each job's latest run is always kept.
+func CleanupJobRuns(now time.Time) error {
+	return database.DB.
+		Where("scheduled_for < ?", now.Add(-jobRunRetention)).
+		Where("scheduled_for < (SELECT MAX(latest.scheduled_for) FROM job_runs latest WHERE latest.job = job_runs.job)").
+		Delete(&models.JobRun{}).Error
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+// countingJob returns a job that records the times it ran for
+func countingJob(name string, catchUp time.Duration) (*Job, *[]time.Time) {
+	var mu sync.Mutex
+	var runs []time.Time
+	job := &Job{Name: name, Period: time.Minute, CatchUp: catchUp, Run: func(now time.Time) error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs = append(runs, now)
+		return nil
+	}}
+	return job, &runs
+}
+
+func TestRunJobClaimsSlot(t *testing.T) {
+	useTestDB(t)
+
+	job, runs := countingJob("claims", 0)
+	at := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"first run", at, true},
+		{"same slot", at.Add(30 * time.Second), false},
+		{"next slot", at.Add(time.Minute), true},
+		{"earlier slot", at.Add(-time.Minute), true},
+	}
+	for _, tt := range tests {
+		if got := RunJob(job, tt.at, false); got != tt.want {
+			t.Errorf("%s: RunJob = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if len(*runs) != 3 {
+		t.Errorf("job ran %d times, want 3", len(*runs))
+	}
+
+	// Another server that claimed the slot first keeps this one from running
+	taken := at.Add(2 * time.Minute)
+	database.DB.Create(&models.JobRun{Job: job.Name, ScheduledFor: taken, StartedAt: time.Now().UTC()})
+	if RunJob(job, taken, false) {
+		t.Error("RunJob ran for a slot claimed elsewhere")
+	}
+
+	var wg sync.WaitGroup
+	ran := make([]bool, 10)
+	for i := range ran {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ran[i] = RunJob(job, at.Add(3*time.Minute), false)
+		}(i)
+	}
+	wg.Wait()
+	claimed := 0
+	for _, r := range ran {
+		if r {
+			claimed++
+		}
+	}
+	if claimed != 1 {
+		t.Errorf("%d concurrent runs claimed the slot, want 1", claimed)
+	}
+}
+
+func TestRunJobRecordsFailures(t *testing.T) {
+	useTestDB(t)
+
+	at := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		run  func(time.Time) error
+		want string
+	}{
+		{"succeeds", func(time.Time) error { return nil }, ""},
+		{"fails", func(time.Time) error { return errors.New("disk full") }, "disk full"},
+		{"panics", func(time.Time) error { panic("nil map") }, "panic: nil map"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{Name: tt.name, Period: time.Minute, Run: tt.run}
+			if !RunJob(job, at, false) {
+				t.Fatal("RunJob didn't run")
+			}
+
+			var run models.JobRun
+			database.DB.Where("job = ?", job.Name).First(&run)
+			if run.FinishedAt == nil || run.Error != tt.want {
+				t.Errorf("run finished at %v with error %q, want finished with %q", run.FinishedAt, run.Error, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatchUpJob(t *testing.T) {
+	useTestDB(t)
+
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		catchUp  time.Duration
+		lastRun  time.Duration // Before now; zero when the job never ran
+		replayed int
+	}{
+		{"never ran", time.Hour, 0, 0},
+		{"missed four hours", time.Hour, 5 * time.Hour, 4},
+		{"up to date", time.Hour, time.Hour, 0},
+		{"no catching up", 0, 5 * time.Hour, 0},
+		{"down past the cap", 24 * time.Hour, maxCatchUp + 10*24*time.Hour, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, runs := countingJob(tt.name, tt.catchUp)
+			if tt.lastRun != 0 {
+				database.DB.Create(&models.JobRun{Job: job.Name, ScheduledFor: now.Add(-tt.lastRun), StartedAt: now})
+			}
+
+			CatchUpJob(job, now)
+
+			if len(*runs) != tt.replayed+1 {
+				t.Fatalf("job ran %d times, want %d replays and one for now", len(*runs), tt.replayed)
+			}
+			for i, at := range *runs {
+				if i > 0 && !at.After((*runs)[i-1]) {
+					t.Errorf("run %d for %s came after %s", i, at, (*runs)[i-1])
+				}
+			}
+			if last := (*runs)[len(*runs)-1]; !last.Equal(now) {
+				t.Errorf("last run for %s, want now", last)
+			}
+
+			var replayed int64
+			database.DB.Model(&models.JobRun{}).Where("job = ? AND replayed = ?", job.Name, true).Count(&replayed)
+			if replayed != int64(tt.replayed) {
+				t.Errorf("ledger shows %d replayed runs, want %d", replayed, tt.replayed)
+			}
+
+			// Catching up again right away has nothing left to do
+			CatchUpJob(job, now)
+			if len(*runs) != tt.replayed+1 {
+				t.Errorf("second catch-up ran the job %d more times", len(*runs)-tt.replayed-1)
+			}
+		})
+	}
+}
+
+func TestCleanupJobRunsKeepsLatest(t *testing.T) {
+	useTestDB(t)
+
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	for _, run := range []models.JobRun{
+		{Job: "busy", ScheduledFor: now.Add(-10 * 24 * time.Hour)},
+		{Job: "busy", ScheduledFor: now.Add(-time.Hour)},
+		{Job: "idle", ScheduledFor: now.Add(-20 * 24 * time.Hour)},
+		{Job: "idle", ScheduledFor: now.Add(-10 * 24 * time.Hour)},
+	} {
+		run.StartedAt = run.ScheduledFor
+		database.DB.Create(&run)
+	}
+
+	if err := CleanupJobRuns(now); err != nil {
+		t.Fatalf("CleanupJobRuns: %v", err)
+	}
+
+	var left []models.JobRun
+	database.DB.Order("job, scheduled_for").Find(&left)
+	if len(left) != 2 || !left[0].ScheduledFor.Equal(now.Add(-time.Hour)) || !left[1].ScheduledFor.Equal(now.Add(-10*24*time.Hour)) {
+		t.Errorf("kept %+v, want each job's latest run", left)
+	}
+}
@@ -1,39 +1,163 @@
 package utils
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/bluefalconhd/lbd_game/server/config"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var jwtKey = []byte("your_secret_key") // Replace with your secret key
+// Access tokens are short lived; clients renew them with their session's
+// refresh token
+const AccessTokenTTL = 15 * time.Minute
 
 type Claims struct {
 	UserID    uint `json:"user_id"`
 	Privilege int  `json:"privilege"`
+	SessionID uint `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, privilege int) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// jwtKey is one key in the key set, identified by the kid header of the tokens
+// it signs
+type jwtKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	Sign   interface{} // Only set for the current key
+	Verify interface{}
+}
+
+var (
+	signingKey *jwtKey
+	jwtKeys    = map[string]*jwtKey{}
+)
+
+// InitJWT loads the signing key and any retired keys that should still verify
+func InitJWT(cfg config.Config) error {
+	method := jwt.GetSigningMethod(cfg.JWTAlgorithm)
+	switch method {
+	case jwt.SigningMethodHS256, jwt.SigningMethodEdDSA, jwt.SigningMethodRS256:
+	default:
+		return fmt.Errorf("unsupported JWT algorithm %q (use HS256, EdDSA or RS256)", cfg.JWTAlgorithm)
+	}
+
+	current := &jwtKey{ID: cfg.JWTKeyID, Method: method}
+	if method == jwt.SigningMethodHS256 {
+		secret := []byte(cfg.JWTSecret)
+		if len(secret) == 0 {
+			// Tokens won't survive a restart, but refresh tokens will
+			fmt.Println("JWT_SECRET is not set; using a random signing key")
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return err
+			}
+		}
+		current.Sign, current.Verify = secret, secret
+	} else {
+		if cfg.JWTPrivateKeyFile == "" {
+			return fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", method.Alg())
+		}
+		pem, err := os.ReadFile(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return err
+		}
+		if method == jwt.SigningMethodEdDSA {
+			key, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return fmt.Errorf("invalid Ed25519 private key: %w", err)
+			}
+			current.Sign, current.Verify = key, key.(crypto.Signer).Public()
+		} else {
+			key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return fmt.Errorf("invalid RSA private key: %w", err)
+			}
+			current.Sign, current.Verify = key, &key.PublicKey
+		}
+	}
+
+	keys := map[string]*jwtKey{current.ID: current}
+	for _, entry := range cfg.JWTVerifyKeys {
+		id, value, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || value == "" {
+			return fmt.Errorf("invalid JWT verify key %q, expected kid:value", entry)
+		}
+		if _, exists := keys[id]; exists {
+			return fmt.Errorf("duplicate JWT key ID %q", id)
+		}
+
+		key := &jwtKey{ID: id, Method: method}
+		switch method {
+		case jwt.SigningMethodHS256:
+			key.Verify = []byte(value)
+		case jwt.SigningMethodEdDSA, jwt.SigningMethodRS256:
+			pem, err := os.ReadFile(value)
+			if err != nil {
+				return err
+			}
+			if method == jwt.SigningMethodEdDSA {
+				key.Verify, err = jwt.ParseEdPublicKeyFromPEM(pem)
+			} else {
+				key.Verify, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+			}
+			if err != nil {
+				return fmt.Errorf("invalid public key for %q: %w", id, err)
+			}
+		}
+		keys[id] = key
+	}
+
+	signingKey = current
+	jwtKeys = keys
+	return nil
+}
+
+func GenerateToken(userID uint, privilege int, sessionID uint) (string, error) {
+	if signingKey == nil {
+		return "", errors.New("JWT signing key is not configured")
+	}
+
+	expirationTime := time.Now().Add(AccessTokenTTL)
 
 	claims := &Claims{
 		UserID:    userID,
 		Privilege: privilege,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	token := jwt.NewWithClaims(signingKey.Method, claims)
+	token.Header["kid"] = signingKey.ID
+	return token.SignedString(signingKey.Sign)
 }
 
 func VerifyToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
+		kid, _ := token.Header["kid"].(string)
+		key, ok := jwtKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key ID %q", kid)
+		}
+		// Never let the token pick the algorithm
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return key.Verify, nil
 	})
 
 	if err != nil || !token.Valid {
@@ -42,3 +166,39 @@ func VerifyToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// JWK is a public key in JSON Web Key form
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS returns the public keys that verify our tokens. Shared HS256 secrets
+// are never published, so the set is empty in that mode.
+func JWKS() []JWK {
+	keys := []JWK{}
+	for _, key := range jwtKeys {
+		jwk := JWK{KeyID: key.ID, Algorithm: key.Method.Alg(), Use: "sig"}
+		switch public := key.Verify.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		default:
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyID < keys[j].KeyID })
+	return keys
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/config"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestJWTKeyRotation(t *testing.T) {
+	useTestJWT(t, config.Config{JWTKeyID: "old", JWTSecret: "old secret"})
+	oldToken, err := GenerateToken(1, 0, 1)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		oldValid bool
+	}{
+		{"old key kept for verifying", config.Config{JWTKeyID: "new", JWTSecret: "new secret", JWTVerifyKeys: []string{"old:old secret"}}, true},
+		{"old key retired", config.Config{JWTKeyID: "new", JWTSecret: "new secret"}, false},
+		{"old key ID with another secret", config.Config{JWTKeyID: "new", JWTSecret: "new secret", JWTVerifyKeys: []string{"old:guessed"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestJWT(t, tt.cfg)
+
+			if _, err := VerifyToken(oldToken); (err == nil) != tt.oldValid {
+				t.Errorf("VerifyToken(old token) = %v, want valid %v", err, tt.oldValid)
+			}
+
+			newToken, err := GenerateToken(2, 0, 2)
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			claims, err := VerifyToken(newToken)
+			if err != nil || claims.UserID != 2 {
+				t.Errorf("VerifyToken(new token) = %v, %v", claims, err)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenRejects(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("encoding key: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "jwt.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+
+	useTestJWT(t, config.Config{JWTAlgorithm: "EdDSA", JWTKeyID: "ed", JWTPrivateKeyFile: keyFile})
+
+	if keys := JWKS(); len(keys) != 1 || keys[0].KeyType != "OKP" || keys[0].KeyID != "ed" {
+		t.Errorf("JWKS = %+v, want the Ed25519 key", keys)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, expires time.Time) string {
+		token := jwt.NewWithClaims(method, &Claims{
+			UserID:           1,
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(expires)},
+		})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("signing token: %v", err)
+		}
+		return signed
+	}
+
+	later := time.Now().Add(time.Minute)
+	tests := []struct {
+		name  string
+		token string
+	}{
+		// HS256 signed with the public key, which anyone can fetch from JWKS
+		{"algorithm swapped", sign(jwt.SigningMethodHS256, "ed", []byte(private.Public().(ed25519.PublicKey)), later)},
+		{"unknown key ID", sign(jwt.SigningMethodEdDSA, "other", private, later)},
+		{"expired", sign(jwt.SigningMethodEdDSA, "ed", private, time.Now().Add(-time.Minute))},
+		{"garbage", "not.a.token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if claims, err := VerifyToken(tt.token); err == nil {
+				t.Errorf("VerifyToken accepted the token for user %d", claims.UserID)
+			}
+		})
+	}
+
+	if _, err := VerifyToken(sign(jwt.SigningMethodEdDSA, "ed", private, later)); err != nil {
+		t.Errorf("VerifyToken rejected a good token: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm"
+)
+
+// Leaderboard ranking modes
+const (
+	LeaderboardReceived = "verifications_received"
+	LeaderboardGiven    = "verifications_given"
+	LeaderboardPhrases  = "phrases_submitted"
+	LeaderboardStreak   = "survival_streak"
+)
+
+// Leaderboard time ranges
+const (
+	RangeWeek   = "week"
+	RangeSeason = "season"
+	RangeAll    = "all"
+)
+
+var (
+	ErrNoSeason           = errors.New("the game has no seasons")
+	ErrUnknownLeaderboard = errors.New("unknown leaderboard")
+)
+
+type LeaderboardEntry struct {
+	Rank         int      `json:"rank"`
+	UserID       uint     `json:"user_id"`
+	Username     string   `json:"username"`
+	IsEliminated bool     `json:"is_eliminated"`
+	Score        int64    `json:"score"`
+	AvgLatencyMs *float64 `json:"avg_latency_ms,omitempty"`
+}
+
+// rangeWindows selects the IDs of the game's opened windows within the range.
+// Weeks start on Monday in the game's timezone; the season range covers the
+// running season or, between seasons, the most recent one.
+func rangeWindows(gameID uint, rangeName string) (*gorm.DB, error) {
+	now := Now()
+	query := database.DB.Model(&models.SubmissionWindow{}).
+		Select("id").
+		Where("game_id = ? AND open_time <= ?", gameID, now)
+
+	switch rangeName {
+	case RangeAll:
+	case RangeWeek:
+		settings := GetGameSchedule(gameID)
+		schedule, err := CompileSchedule(&settings)
+		if err != nil {
+			return nil, err
+		}
+		today := schedule.LocalDay(now)
+		weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		query = query.Where("open_time >= ?", weekStart.UTC())
+	case RangeSeason:
+		var season models.Season
+		if err := database.DB.Where("game_id = ?", gameID).Order("started_at desc").First(&season).Error; err != nil {
+			return nil, ErrNoSeason
+		}
+		query = query.Where("season_id = ?", season.ID)
+	default:
+		return nil, fmt.Errorf("%w range %q", ErrUnknownLeaderboard, rangeName)
+	}
+
+	return query, nil
+}
+
+// leaderboardScores builds a query yielding user_id and total for the mode,
+// plus avg_latency_ms for phrases.
+func leaderboardScores(mode string, windows *gorm.DB) (*gorm.DB, error) {
+	switch mode {
+	case LeaderboardReceived:
+		return database.DB.Table("verifications").
+			Select("verified_user_id as user_id, COUNT(*) as total").
+			Where("submission_window IN (?) AND status = ? AND deleted_at IS NULL", windows, models.VerificationConfirmed).
+			Group("verified_user_id"), nil
+	case LeaderboardGiven:
+		return database.DB.Table("verifications").
+			Select("verifier_id as user_id, COUNT(*) as total").
+			Where("submission_window IN (?) AND status = ? AND deleted_at IS NULL", windows, models.VerificationConfirmed).
+			Group("verifier_id"), nil
+	case LeaderboardPhrases:
+		return database.DB.Table("phrases").
+			Select("phrases.submitted_by as user_id, COUNT(*) as total, "+
+				"ROUND(AVG((julianday(phrases.created_at) - julianday(submission_windows.open_time)) * 86400000)) as avg_latency_ms").
+			Joins("JOIN submission_windows ON submission_windows.id = phrases.submission_window").
+			Where("phrases.submission_window IN (?) AND phrases.deleted_at IS NULL", windows).
+			Group("phrases.submitted_by"), nil
+	case LeaderboardStreak:
+		// Gaps and islands: numbering each user's verified windows and
+		// subtracting from the window's position groups consecutive runs
+		return database.DB.Raw(`
+			WITH w AS (
+				SELECT id, ROW_NUMBER() OVER (ORDER BY open_time) AS wn
+				FROM submission_windows
+				WHERE id IN (?) AND EXISTS (
+					SELECT 1 FROM phrases
+					WHERE phrases.submission_window = submission_windows.id AND phrases.deleted_at IS NULL)
+			), v AS (
+				SELECT DISTINCT verifications.verified_user_id AS user_id, w.wn
+				FROM verifications JOIN w ON w.id = verifications.submission_window
+				WHERE verifications.status = ? AND verifications.deleted_at IS NULL
+			), islands AS (
+				SELECT user_id, wn - ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY wn) AS grp FROM v
+			)
+			SELECT user_id, MAX(run) AS total
+			FROM (SELECT user_id, COUNT(*) AS run FROM islands GROUP BY user_id, grp)
+			GROUP BY user_id`, windows, models.VerificationConfirmed), nil
+	}
+
+	return nil, fmt.Errorf("%w mode %q", ErrUnknownLeaderboard, mode)
+}
+
+// GetLeaderboard ranks a game's members by the mode over the range. It returns
+// one page of entries and the total number of members.
+func GetLeaderboard(gameID uint, mode, rangeName string, page, pageSize int) ([]LeaderboardEntry, int64, error) {
+	windows, err := rangeWindows(gameID, rangeName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	scores, err := leaderboardScores(mode, windows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Members with the same score share a rank
+	columns := "game_members.user_id, users.username, game_members.is_eliminated, COALESCE(scores.total, 0) as score"
+	ranking := "COALESCE(scores.total, 0) desc"
+	order := "score desc, users.username asc"
+	if mode == LeaderboardPhrases {
+		// Fastest fingers win ties
+		columns += ", scores.avg_latency_ms"
+		ranking += ", scores.avg_latency_ms asc"
+		order = "score desc, scores.avg_latency_ms asc, users.username asc"
+	}
+	columns += ", RANK() OVER (ORDER BY " + ranking + ") as rank"
+
+	query := database.DB.Table("game_members").
+		Joins("JOIN users ON users.id = game_members.user_id AND users.deleted_at IS NULL").
+		Joins("LEFT JOIN (?) AS scores ON scores.user_id = game_members.user_id", scores).
+		Where("game_members.game_id = ?", gameID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []LeaderboardEntry
+	if err := query.Select(columns).
+		Order(order).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
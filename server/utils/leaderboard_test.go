@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+func TestLeaderboardSharesRanks(t *testing.T) {
+	useTestDB(t)
+
+	game := createTestGame(t)
+	members := createTestMembers(t, game.ID, 4)
+
+	// The first two members are verified twice, the third once
+	received := [][]uint{{members[0], members[1], members[2]}, {members[0], members[1]}}
+	for day, verified := range received {
+		openTime := Now().AddDate(0, 0, -day-1)
+		window := models.SubmissionWindow{GameID: game.ID, Phase: models.WindowScored, OpenTime: openTime, CloseTime: openTime.Add(time.Hour)}
+		if err := database.DB.Create(&window).Error; err != nil {
+			t.Fatalf("creating window: %v", err)
+		}
+		for _, userID := range verified {
+			if err := database.DB.Create(&models.Verification{
+				GameID: game.ID, VerifiedUserID: userID, VerifierID: members[3],
+				SubmissionWindow: window.ID, Status: models.VerificationConfirmed,
+			}).Error; err != nil {
+				t.Fatalf("creating verification: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		page, pageSize int
+		ranks          []int
+	}{
+		{1, 10, []int{1, 1, 3, 4}},
+		{1, 2, []int{1, 1}},
+		{2, 2, []int{3, 4}},
+	}
+
+	for _, tt := range tests {
+		entries, total, err := GetLeaderboard(game.ID, LeaderboardReceived, RangeAll, tt.page, tt.pageSize)
+		if err != nil {
+			t.Fatalf("GetLeaderboard: %v", err)
+		}
+		if total != 4 {
+			t.Errorf("total = %d, want 4", total)
+		}
+		if len(entries) != len(tt.ranks) {
+			t.Fatalf("page %d of %d: got %d entries, want %d", tt.page, tt.pageSize, len(entries), len(tt.ranks))
+		}
+		for i, entry := range entries {
+			if entry.Rank != tt.ranks[i] {
+				t.Errorf("page %d of %d: %s (score %d) ranked %d, want %d",
+					tt.page, tt.pageSize, entry.Username, entry.Score, entry.Rank, tt.ranks[i])
+			}
+		}
+	}
+}
+
+func TestStandingsShareRanks(t *testing.T) {
+	useTestDB(t)
+
+	game := createTestGame(t)
+	members := createTestMembers(t, game.ID, 5)
+	season, err := StartSeason(game.ID, "ties", nil)
+	if err != nil {
+		t.Fatalf("StartSeason: %v", err)
+	}
+
+	// Two members go out in the same pass and one in an earlier pass
+	eliminate := func(userID uint, at time.Time) {
+		database.DB.Model(&models.GameMember{}).Where("game_id = ? AND user_id = ?", game.ID, userID).
+			Updates(map[string]interface{}{"is_eliminated": true, "eliminated_at": at})
+	}
+	pass := Now().Add(-time.Hour)
+	eliminate(members[2], pass)
+	eliminate(members[3], pass)
+	eliminate(members[4], pass.Add(-24*time.Hour))
+
+	standings, err := ComputeStandings(game.ID, season.ID)
+	if err != nil {
+		t.Fatalf("ComputeStandings: %v", err)
+	}
+
+	want := map[uint]int{members[0]: 1, members[1]: 1, members[2]: 3, members[3]: 3, members[4]: 5}
+	for _, standing := range standings {
+		if standing.Rank != want[standing.UserID] {
+			t.Errorf("user %d ranked %d, want %d", standing.UserID, standing.Rank, want[standing.UserID])
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+// AttemptRecord is what a throttle remembers about one key
+type AttemptRecord struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// AttemptStore holds attempt records for a LoginThrottle. The in-memory store
+// suits a single server; running several would need a shared store.
+type AttemptStore interface {
+	Get(key string) (AttemptRecord, bool)
+	Set(key string, record AttemptRecord)
+	Delete(key string)
+}
+
+// MemoryAttemptStore is an AttemptStore that lives in process memory and
+// forgets records that have been quiet for longer than ttl
+type MemoryAttemptStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	records map[string]AttemptRecord
+}
+
+func NewMemoryAttemptStore(ttl time.Duration) *MemoryAttemptStore {
+	return &MemoryAttemptStore{ttl: ttl, records: make(map[string]AttemptRecord)}
+}
+
+func (s *MemoryAttemptStore) Get(key string) (AttemptRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if ok && s.expired(record, time.Now()) {
+		delete(s.records, key)
+		return AttemptRecord{}, false
+	}
+	return record, ok
+}
+
+func (s *MemoryAttemptStore) Set(key string, record AttemptRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = record
+	if len(s.records) > 10000 {
+		now := time.Now()
+		for k, r := range s.records {
+			if s.expired(r, now) {
+				delete(s.records, k)
+			}
+		}
+	}
+}
+
+func (s *MemoryAttemptStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+}
+
+func (s *MemoryAttemptStore) expired(record AttemptRecord, now time.Time) bool {
+	return now.Sub(record.LastFailure) > s.ttl && now.After(record.LockedUntil)
+}
+
+// ThrottlePolicy decides how long a key waits after repeated failures. The
+// first FreeFailures are not delayed; after that the delay doubles from
+// BaseDelay up to MaxDelay, and at MaxFailures the key is locked for Lockout.
+type ThrottlePolicy struct {
+	FreeFailures int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	MaxFailures  int
+	Lockout      time.Duration
+}
+
+func (p ThrottlePolicy) delay(failures int) time.Duration {
+	if failures >= p.MaxFailures {
+		return p.Lockout
+	}
+	if failures <= p.FreeFailures {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeFailures + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// LoginThrottle applies a ThrottlePolicy to failed attempts per key
+type LoginThrottle struct {
+	store  AttemptStore
+	policy ThrottlePolicy
+}
+
+func NewLoginThrottle(store AttemptStore, policy ThrottlePolicy) *LoginThrottle {
+	return &LoginThrottle{store: store, policy: policy}
+}
+
+// Wait returns how long key must wait before its next attempt
+func (t *LoginThrottle) Wait(key string) time.Duration {
+	record, ok := t.store.Get(key)
+	if !ok {
+		return 0
+	}
+	if wait := time.Until(record.LockedUntil); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Fail records a failed attempt and returns how long key must now wait
+func (t *LoginThrottle) Fail(key string) time.Duration {
+	record, _ := t.store.Get(key)
+	record.Failures++
+	record.LastFailure = time.Now()
+
+	delay := t.policy.delay(record.Failures)
+	// Past MaxFailures every further failure locks the key again, until it
+	// has been quiet long enough for the store to forget it
+	record.LockedUntil = record.LastFailure.Add(delay)
+
+	t.store.Set(key, record)
+	return delay
+}
+
+// Reset clears key after a successful attempt
+func (t *LoginThrottle) Reset(key string) {
+	t.store.Delete(key)
+}
+
+// Forgive takes one failure off key after a successful attempt. Unlike Reset
+// it only pays off a mistake, so a shared key such as an IP behind a NAT
+// recovers as its users log in, while someone guessing across accounts still
+// builds up failures faster than their own logins forgive them.
+func (t *LoginThrottle) Forgive(key string) {
+	record, ok := t.store.Get(key)
+	if !ok {
+		return
+	}
+	record.Failures--
+	if record.Failures <= 0 {
+		t.store.Delete(key)
+		return
+	}
+	record.LockedUntil = record.LastFailure.Add(t.policy.delay(record.Failures))
+	t.store.Set(key, record)
+}
+
+// RecordFailedLogin stores a failed attempt for admins to review
+func RecordFailedLogin(username string, userID *uint, ipAddress, userAgent, reason string) {
+	if err := database.DB.Create(&models.FailedLogin{
+		Username:  username,
+		UserID:    userID,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		Reason:    reason,
+	}).Error; err != nil {
+		fmt.Printf("Failed to record failed login for %s: %v\n", username, err)
+	}
+}
+
+// CleanupFailedLogins drops failed login records older than 90 days
+func CleanupFailedLogins(now time.Time) error {
+	cutoff := now.UTC().AddDate(0, 0, -90)
+	return database.DB.Where("created_at < ?", cutoff).Delete(&models.FailedLogin{}).Error
+}
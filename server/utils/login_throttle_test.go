@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+var testPolicy = ThrottlePolicy{
+	FreeFailures: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     8 * time.Second,
+	MaxFailures:  10,
+	Lockout:      15 * time.Minute,
+}
+
+func TestThrottlePolicyDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{7, 8 * time.Second},
+		{9, 8 * time.Second},
+		{10, 15 * time.Minute},
+		{25, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := testPolicy.delay(tt.failures); got != tt.want {
+			t.Errorf("delay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottle(t *testing.T) {
+	throttle := NewLoginThrottle(NewMemoryAttemptStore(time.Hour), testPolicy)
+
+	for i := 0; i < testPolicy.FreeFailures; i++ {
+		if delay := throttle.Fail("alice"); delay != 0 {
+			t.Fatalf("failure %d delayed %s, want none", i+1, delay)
+		}
+	}
+	if wait := throttle.Wait("alice"); wait != 0 {
+		t.Errorf("Wait after free failures = %s, want 0", wait)
+	}
+
+	throttle.Fail("alice")
+	if wait := throttle.Wait("alice"); wait <= 0 || wait > time.Second {
+		t.Errorf("Wait after a delayed failure = %s, want up to 1s", wait)
+	}
+	if wait := throttle.Wait("bob"); wait != 0 {
+		t.Errorf("Wait for another key = %s, want 0", wait)
+	}
+
+	for i := testPolicy.FreeFailures + 1; i < testPolicy.MaxFailures; i++ {
+		throttle.Fail("alice")
+	}
+	if wait := throttle.Wait("alice"); wait <= 8*time.Second {
+		t.Errorf("Wait at MaxFailures = %s, want the lockout", wait)
+	}
+
+	throttle.Reset("alice")
+	if wait := throttle.Wait("alice"); wait != 0 {
+		t.Errorf("Wait after Reset = %s, want 0", wait)
+	}
+}
+
+func TestLoginThrottleForgive(t *testing.T) {
+	throttle := NewLoginThrottle(NewMemoryAttemptStore(time.Hour), testPolicy)
+
+	for i := 0; i < testPolicy.MaxFailures; i++ {
+		throttle.Fail("10.0.0.1")
+	}
+
+	// One success pays off one failure, dropping below the lockout
+	throttle.Forgive("10.0.0.1")
+	if wait := throttle.Wait("10.0.0.1"); wait <= 0 || wait > 8*time.Second {
+		t.Errorf("Wait after forgiving one failure = %s, want the capped delay", wait)
+	}
+
+	for i := 0; i < testPolicy.MaxFailures; i++ {
+		throttle.Forgive("10.0.0.1")
+	}
+	if _, ok := throttle.store.Get("10.0.0.1"); ok {
+		t.Error("record kept after every failure was forgiven")
+	}
+
+	// Forgiving a clean key leaves nothing behind
+	throttle.Forgive("10.0.0.2")
+	if _, ok := throttle.store.Get("10.0.0.2"); ok {
+		t.Error("Forgive created a record")
+	}
+}
+
+func TestMemoryAttemptStoreForgets(t *testing.T) {
+	store := NewMemoryAttemptStore(time.Millisecond)
+	now := time.Now()
+
+	store.Set("quiet", AttemptRecord{Failures: 2, LastFailure: now.Add(-time.Second)})
+	store.Set("locked", AttemptRecord{Failures: 10, LastFailure: now.Add(-time.Second), LockedUntil: now.Add(time.Hour)})
+
+	if _, ok := store.Get("quiet"); ok {
+		t.Error("quiet record outlived its ttl")
+	}
+	if _, ok := store.Get("locked"); !ok {
+		t.Error("locked record forgotten before its lockout ended")
+	}
+}
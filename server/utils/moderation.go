@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bluefalconhd/lbd_game/server/config"
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+// Codes returned with rejected phrases so clients can explain the problem
+const (
+	PhraseEmpty        = "phrase_empty"
+	PhraseTooFewWords  = "phrase_too_few_words"
+	PhraseTooManyWords = "phrase_too_many_words"
+	PhraseTooShort     = "phrase_too_short"
+	PhraseTooLong      = "phrase_too_long"
+	PhraseBannedWord   = "phrase_banned_word"
+	PhraseDuplicate    = "phrase_duplicate"
+)
+
+// Phrases at least this similar to a recent one count as duplicates
+const duplicateSimilarity = 0.85
+
+// PhraseError is a phrase rejected by moderation
+type PhraseError struct {
+	Code    string
+	Message string
+}
+
+func (e *PhraseError) Error() string {
+	return e.Message
+}
+
+type phraseLimits struct {
+	minWords, maxWords int
+	minChars, maxChars int
+	duplicateDays      int
+}
+
+var limits = phraseLimits{minWords: 2, maxWords: 15, minChars: 5, maxChars: 120, duplicateDays: 30}
+
+func SetPhraseLimits(cfg config.Config) error {
+	if cfg.PhraseMinWords < 1 || cfg.PhraseMaxWords < cfg.PhraseMinWords {
+		return fmt.Errorf("invalid phrase word limits %d-%d", cfg.PhraseMinWords, cfg.PhraseMaxWords)
+	}
+	if cfg.PhraseMinChars < 1 || cfg.PhraseMaxChars < cfg.PhraseMinChars {
+		return fmt.Errorf("invalid phrase length limits %d-%d", cfg.PhraseMinChars, cfg.PhraseMaxChars)
+	}
+	limits = phraseLimits{
+		minWords:      cfg.PhraseMinWords,
+		maxWords:      cfg.PhraseMaxWords,
+		minChars:      cfg.PhraseMinChars,
+		maxChars:      cfg.PhraseMaxChars,
+		duplicateDays: cfg.PhraseDuplicateDays,
+	}
+	return nil
+}
+
+// NormalizePhrase lowercases text and reduces it to words separated by single
+// spaces, so punctuation and spacing don't affect comparisons
+func NormalizePhrase(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// ModeratePhrase checks a phrase before it is stored for a game's window and
+// returns it with its whitespace tidied. The window's own phrase is not a
+// duplicate, so an edit or a losing racer isn't reported as one.
+func ModeratePhrase(gameID uint, content string, windowID uint) (string, error) {
+	content = strings.Join(strings.Fields(content), " ")
+	normalized := NormalizePhrase(content)
+	if normalized == "" {
+		return "", &PhraseError{PhraseEmpty, "Phrase must contain letters or numbers"}
+	}
+
+	chars := utf8.RuneCountInString(content)
+	if chars < limits.minChars {
+		return "", &PhraseError{PhraseTooShort, fmt.Sprintf("Phrase must be at least %d characters", limits.minChars)}
+	}
+	if chars > limits.maxChars {
+		return "", &PhraseError{PhraseTooLong, fmt.Sprintf("Phrase must be at most %d characters", limits.maxChars)}
+	}
+
+	words := strings.Fields(normalized)
+	if len(words) < limits.minWords {
+		return "", &PhraseError{PhraseTooFewWords, fmt.Sprintf("Phrase must have at least %d words", limits.minWords)}
+	}
+	if len(words) > limits.maxWords {
+		return "", &PhraseError{PhraseTooManyWords, fmt.Sprintf("Phrase must have at most %d words", limits.maxWords)}
+	}
+
+	var banned []string
+	if err := database.DB.Model(&models.BannedWord{}).Where("game_id = ?", gameID).Pluck("word", &banned).Error; err != nil {
+		return "", err
+	}
+	padded := " " + normalized + " "
+	for _, word := range banned {
+		// Match whole words so banning "ass" doesn't catch "class"
+		if strings.Contains(padded, " "+word+" ") {
+			return "", &PhraseError{PhraseBannedWord, "Phrase contains a word that isn't allowed"}
+		}
+	}
+
+	var recent []models.Phrase
+	since := Now().AddDate(0, 0, -limits.duplicateDays)
+	if err := database.DB.Where("game_id = ? AND submission_window <> ? AND created_at >= ?", gameID, windowID, since).
+		Find(&recent).Error; err != nil {
+		return "", err
+	}
+	for _, phrase := range recent {
+		if similarPhrases(normalized, NormalizePhrase(phrase.Content)) {
+			return "", &PhraseError{PhraseDuplicate, fmt.Sprintf("Phrase is too similar to one used on %s", phrase.CreatedAt.Format("Jan 2"))}
+		}
+	}
+
+	return content, nil
+}
+
+// similarPhrases reports whether two normalized phrases are near-duplicates:
+// the same words in any order, or only a few characters apart
+func similarPhrases(a, b string) bool {
+	if a == b || sortedWords(a) == sortedWords(b) {
+		return true
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1-float64(levenshtein(ra, rb))/float64(longest) >= duplicateSimilarity
+}
+
+func sortedWords(s string) string {
+	words := strings.Fields(s)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
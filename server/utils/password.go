@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	ResetCodeTTL         = 24 * time.Hour
+	maxResetCodeAttempts = 5
+)
+
+var (
+	ErrResetCodeInvalid = errors.New("invalid or expired reset code")
+	ErrWrongPassword    = errors.New("current password is incorrect")
+)
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+func hashResetCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(NormalizeCode(code), "-", "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueResetCode creates a one-time reset code for a user, replacing any
+// code they were given before. The code is formatted in groups of four.
+func IssueResetCode(userID, issuedBy uint) (string, time.Time, error) {
+	code, err := GenerateCode(12)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().UTC().Add(ResetCodeTTL)
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", userID).Delete(&models.PasswordReset{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordReset{
+			UserID:    userID,
+			CodeHash:  hashResetCode(code),
+			IssuedBy:  issuedBy,
+			ExpiresAt: expiresAt,
+		}).Error
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12], expiresAt, nil
+}
+
+// RedeemResetCode sets a new password for username if code is their live
+// reset code. A code stops working after it is used, when it expires, or
+// after too many wrong guesses. Every session the user had is revoked.
+func RedeemResetCode(username, code, newPassword string) error {
+	var user models.User
+	if err := database.DB.Where("username = ?", username).First(&user).Error; err != nil {
+		return ErrResetCodeInvalid
+	}
+
+	now := time.Now().UTC()
+	var reset models.PasswordReset
+	if err := database.DB.Where("user_id = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?",
+		user.ID, now, maxResetCodeAttempts).First(&reset).Error; err != nil {
+		return ErrResetCodeInvalid
+	}
+
+	if reset.CodeHash != hashResetCode(code) {
+		database.DB.Model(&reset).Update("attempts", gorm.Expr("attempts + 1"))
+		return ErrResetCodeInvalid
+	}
+
+	hash, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		// Guard on used_at so the code can't be redeemed twice concurrently
+		result := tx.Model(&models.PasswordReset{}).
+			Where("id = ? AND used_at IS NULL", reset.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrResetCodeInvalid
+		}
+
+		if err := tx.Model(&user).Update("password_hash", hash).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// ChangePassword replaces a user's password after checking the current one,
+// and signs out every session except keepSessionID.
+func ChangePassword(user *models.User, currentPassword, newPassword string, keepSessionID uint) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return ErrWrongPassword
+	}
+
+	hash, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password_hash", hash).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Session{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", user.ID, keepSessionID).
+			Update("revoked_at", time.Now().UTC()).Error
+	})
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bluefalconhd/lbd_game/server/config"
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordIs reports whether the user's stored password is now password
+func passwordIs(t *testing.T, userID uint, password string) bool {
+	t.Helper()
+
+	var user models.User
+	database.DB.First(&user, userID)
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+func TestRedeemResetCode(t *testing.T) {
+	useTestDB(t)
+	useTestJWT(t, config.Config{JWTKeyID: "test", JWTSecret: "secret"})
+
+	user := createTestUser(t, "old password")
+	session, _ := CreateSession(user, "test", "127.0.0.1")
+
+	stale, _, err := IssueResetCode(user.ID, 1)
+	if err != nil {
+		t.Fatalf("IssueResetCode: %v", err)
+	}
+	code, _, err := IssueResetCode(user.ID, 1)
+	if err != nil {
+		t.Fatalf("IssueResetCode: %v", err)
+	}
+
+	if err := RedeemResetCode(user.Username, stale, "new password"); !errors.Is(err, ErrResetCodeInvalid) {
+		t.Errorf("redeeming a replaced code = %v, want ErrResetCodeInvalid", err)
+	}
+	if err := RedeemResetCode("nobody", code, "new password"); !errors.Is(err, ErrResetCodeInvalid) {
+		t.Errorf("redeeming for another username = %v, want ErrResetCodeInvalid", err)
+	}
+
+	// Codes are read out by hand, so case and dashes don't matter
+	typed := strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	if err := RedeemResetCode(user.Username, typed, "new password"); err != nil {
+		t.Fatalf("RedeemResetCode: %v", err)
+	}
+	if !passwordIs(t, user.ID, "new password") {
+		t.Error("password wasn't changed")
+	}
+	if _, err := AuthenticateSession(user.ID, session.SessionID); !errors.Is(err, ErrSessionInvalid) {
+		t.Errorf("session survived a reset: %v", err)
+	}
+
+	if err := RedeemResetCode(user.Username, code, "another password"); !errors.Is(err, ErrResetCodeInvalid) {
+		t.Errorf("redeeming a code twice = %v, want ErrResetCodeInvalid", err)
+	}
+}
+
+func TestResetCodeGuessLimit(t *testing.T) {
+	useTestDB(t)
+
+	user := createTestUser(t, "old password")
+	code, _, err := IssueResetCode(user.ID, 1)
+	if err != nil {
+		t.Fatalf("IssueResetCode: %v", err)
+	}
+
+	for i := 0; i < maxResetCodeAttempts; i++ {
+		if err := RedeemResetCode(user.Username, "AAAA-AAAA-AAAA", "new password"); !errors.Is(err, ErrResetCodeInvalid) {
+			t.Fatalf("wrong guess %d = %v, want ErrResetCodeInvalid", i+1, err)
+		}
+	}
+	if err := RedeemResetCode(user.Username, code, "new password"); !errors.Is(err, ErrResetCodeInvalid) {
+		t.Errorf("right code after too many guesses = %v, want ErrResetCodeInvalid", err)
+	}
+	if !passwordIs(t, user.ID, "old password") {
+		t.Error("password changed after the code was used up")
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	useTestDB(t)
+	useTestJWT(t, config.Config{JWTKeyID: "test", JWTSecret: "secret"})
+
+	user := createTestUser(t, "old password")
+	current, _ := CreateSession(user, "laptop", "127.0.0.1")
+	other, _ := CreateSession(user, "phone", "127.0.0.1")
+
+	if err := ChangePassword(user, "wrong password", "new password", current.SessionID); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("ChangePassword with the wrong password = %v, want ErrWrongPassword", err)
+	}
+	if _, err := AuthenticateSession(user.ID, other.SessionID); err != nil {
+		t.Errorf("failed change signed out another session: %v", err)
+	}
+
+	if err := ChangePassword(user, "old password", "new password", current.SessionID); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	if !passwordIs(t, user.ID, "new password") {
+		t.Error("password wasn't changed")
+	}
+	if _, err := AuthenticateSession(user.ID, current.SessionID); err != nil {
+		t.Errorf("current session was signed out: %v", err)
+	}
+	if _, err := AuthenticateSession(user.ID, other.SessionID); !errors.Is(err, ErrSessionInvalid) {
+		t.Errorf("other session survived the change: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+// phraseSearchFTS is set when SQLite has FTS5, which go-sqlite3 only includes
+// when built with -tags sqlite_fts5, as the Makefile does. Otherwise search
+// falls back to LIKE.
+var phraseSearchFTS bool
+
+var phraseSearchTriggers = []string{"phrases_fts_insert", "phrases_fts_delete", "phrases_fts_update"}
+
+// InitPhraseSearch sets up the full-text index over phrase content, kept in
+// sync with the phrases table by triggers
+func InitPhraseSearch() {
+	db := database.DB
+
+	var available bool
+	db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available)
+	if !available {
+		fmt.Println("WARNING: SQLite was built without FTS5, so phrase search falls back to LIKE " +
+			"with no ranking or prefix matching. Build with -tags sqlite_fts5 (make build) to enable it.")
+		dropPhraseSearchTriggers()
+		return
+	}
+
+	statements := []string{
+		"CREATE VIRTUAL TABLE IF NOT EXISTS phrases_fts USING fts5(content, content='phrases', content_rowid='id')",
+		`CREATE TRIGGER IF NOT EXISTS phrases_fts_insert AFTER INSERT ON phrases BEGIN
+			INSERT INTO phrases_fts(rowid, content) VALUES (new.id, new.content);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS phrases_fts_delete AFTER DELETE ON phrases BEGIN
+			INSERT INTO phrases_fts(phrases_fts, rowid, content) VALUES ('delete', old.id, old.content);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS phrases_fts_update AFTER UPDATE OF content ON phrases BEGIN
+			INSERT INTO phrases_fts(phrases_fts, rowid, content) VALUES ('delete', old.id, old.content);
+			INSERT INTO phrases_fts(rowid, content) VALUES (new.id, new.content);
+		END`,
+		// Index phrases written while the triggers didn't exist
+		"INSERT INTO phrases_fts(phrases_fts) VALUES ('rebuild')",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			fmt.Printf("Failed to set up phrase search, searching phrases with LIKE: %v\n", err)
+			dropPhraseSearchTriggers()
+			return
+		}
+	}
+
+	phraseSearchFTS = true
+}
+
+// dropPhraseSearchTriggers removes the index triggers, which would make every
+// phrase write fail if FTS5 went missing
+func dropPhraseSearchTriggers() {
+	for _, trigger := range phraseSearchTriggers {
+		database.DB.Exec("DROP TRIGGER IF EXISTS " + trigger)
+	}
+}
+
+// ArchivedPhrase is a phrase from the archive with the context of its window
+type ArchivedPhrase struct {
+	ID            uint      `json:"id"`
+	Content       string    `json:"content"`
+	SubmittedBy   uint      `json:"submitted_by"`
+	Username      string    `json:"username"`  // Empty for phrases from the bank
+	FromBank      bool      `json:"from_bank"` // Picked from the bank because nobody claimed the window
+	WindowID      uint      `json:"window_id"`
+	OpenTime      time.Time `json:"open_time"`
+	SubmittedAt   time.Time `json:"submitted_at"`
+	LatencyMs     int64     `json:"latency_ms" gorm:"-"` // From the window opening to the submission
+	Verifications int64     `json:"verifications"`       // Confirmed verifications in the window
+}
+
+// PhraseArchiveQuery filters the archive. Search matches all of its words.
+type PhraseArchiveQuery struct {
+	GameID      uint
+	Search      string
+	SubmittedBy uint
+	Page        int
+	PageSize    int
+}
+
+// GetPhraseArchive pages through a game's phrases, newest first
+func GetPhraseArchive(q PhraseArchiveQuery) ([]ArchivedPhrase, int64, error) {
+	query := database.DB.Table("phrases").
+		Joins("LEFT JOIN users ON users.id = phrases.submitted_by").
+		Joins("JOIN submission_windows ON submission_windows.id = phrases.submission_window").
+		Where("phrases.game_id = ? AND phrases.deleted_at IS NULL", q.GameID)
+
+	if q.SubmittedBy != 0 {
+		query = query.Where("phrases.submitted_by = ?", q.SubmittedBy)
+	}
+
+	if words := strings.Fields(NormalizePhrase(q.Search)); len(words) > 0 {
+		if phraseSearchFTS {
+			// Quote each word so user input can't use FTS query syntax
+			terms := make([]string, len(words))
+			for i, word := range words {
+				terms[i] = `"` + word + `"*`
+			}
+			query = query.Where("phrases.id IN (SELECT rowid FROM phrases_fts WHERE phrases_fts MATCH ?)", strings.Join(terms, " "))
+		} else {
+			// Normalized words are only letters and digits, so need no escaping
+			for _, word := range words {
+				query = query.Where("LOWER(phrases.content) LIKE ?", "%"+word+"%")
+			}
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var phrases []ArchivedPhrase
+	if err := query.
+		Select("phrases.id, phrases.content, phrases.submitted_by, COALESCE(users.username, '') as username, "+
+			"phrases.bank_phrase_id IS NOT NULL as from_bank, "+
+			"phrases.submission_window as window_id, submission_windows.open_time, phrases.created_at as submitted_at, "+
+			"(SELECT COUNT(*) FROM verifications WHERE verifications.submission_window = phrases.submission_window "+
+			"AND verifications.status = ? AND verifications.deleted_at IS NULL) as verifications", models.VerificationConfirmed).
+		Order("submission_windows.open_time desc, phrases.id desc").
+		Offset((q.Page - 1) * q.PageSize).
+		Limit(q.PageSize).
+		Find(&phrases).Error; err != nil {
+		return nil, 0, err
+	}
+
+	for i := range phrases {
+		phrases[i].LatencyMs = phrases[i].SubmittedAt.Sub(phrases[i].OpenTime).Milliseconds()
+	}
+
+	return phrases, total, nil
+}
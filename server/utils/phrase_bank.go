@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm"
+)
+
+// ErrBankEmpty means the game has no approved phrases left to use
+var ErrBankEmpty = errors.New("no unused phrases in the bank")
+
+// ModerateBankPhrase checks a phrase before it joins the game's bank. On top
+// of the usual rules it mustn't repeat an entry that is waiting to be used.
+func ModerateBankPhrase(gameID uint, content string) (string, error) {
+	content, err := ModeratePhrase(gameID, content, 0)
+	if err != nil {
+		return "", err
+	}
+
+	var queued []string
+	if err := database.DB.Model(&models.BankPhrase{}).
+		Where("game_id = ? AND status <> ? AND used_in_window IS NULL", gameID, models.BankPhraseRejected).
+		Pluck("content", &queued).Error; err != nil {
+		return "", err
+	}
+	normalized := NormalizePhrase(content)
+	for _, other := range queued {
+		if similarPhrases(normalized, NormalizePhrase(other)) {
+			return "", &PhraseError{PhraseDuplicate, "Phrase is too similar to one already in the bank"}
+		}
+	}
+
+	return content, nil
+}
+
+// FillUnclaimedWindows gives each game's open window a phrase from its bank
+// once the game's deadline has passed without anyone submitting one
+func FillUnclaimedWindows(now time.Time) error {
+	var games []models.Game
+	if err := database.DB.Where("phrase_deadline > 0").Find(&games).Error; err != nil {
+		return fmt.Errorf("fetching games: %w", err)
+	}
+
+	for _, game := range games {
+		window, err := currentWindowAt(game.ID, now)
+		if err != nil || window.Phase != models.WindowOpen {
+			continue
+		}
+		if now.Before(window.OpenTime.Add(time.Duration(game.PhraseDeadline) * time.Minute)) {
+			continue
+		}
+
+		phrase, err := FillFromBank(window, now)
+		if errors.Is(err, ErrBankEmpty) {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Failed to fill window %d from the phrase bank: %v\n", window.ID, err)
+			continue
+		}
+		if phrase != nil {
+			TriggerUpdate(game.ID, UpdatePhrase)
+			TriggerUpdate(game.ID, UpdateWindow)
+		}
+	}
+	return nil
+}
+
+// FillFromBank makes a random approved, unused bank phrase the open window's
+// phrase. It returns nil without an error when the window already has one.
+func FillFromBank(window *models.SubmissionWindow, now time.Time) (*models.Phrase, error) {
+	var phrase *models.Phrase
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var claimed int64
+		if err := tx.Model(&models.Phrase{}).Where("submission_window = ?", window.ID).Count(&claimed).Error; err != nil {
+			return err
+		}
+		if claimed > 0 {
+			return nil
+		}
+
+		var entry models.BankPhrase
+		if err := tx.Where("game_id = ? AND status = ? AND used_in_window IS NULL", window.GameID, models.BankPhraseApproved).
+			Order("RANDOM()").
+			First(&entry).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBankEmpty
+			}
+			return err
+		}
+
+		phrase = &models.Phrase{
+			GameID:           window.GameID,
+			Content:          entry.Content,
+			SubmissionWindow: window.ID,
+			BankPhraseID:     &entry.ID,
+			CreatedAt:        now,
+		}
+		if err := tx.Create(phrase).Error; err != nil {
+			return err
+		}
+		if err := lockWindow(tx, window, now); err != nil {
+			return err
+		}
+
+		return tx.Model(&entry).Updates(map[string]interface{}{"used_in_window": window.ID, "used_at": now}).Error
+	})
+	if err != nil {
+		// A player may have claimed the window while the phrase was picked
+		var claimed int64
+		if database.DB.Model(&models.Phrase{}).Where("submission_window = ?", window.ID).Count(&claimed); claimed > 0 {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return phrase, nil
+}
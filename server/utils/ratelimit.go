@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter allows up to limit events per key within a sliding window
+type RateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string][]time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window, hits: make(map[string][]time.Time)}
+}
+
+// Allow records an event for key and reports whether it is within the limit
+func (l *RateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	recent := l.hits[key][:0]
+	for _, t := range l.hits[key] {
+		if now.Sub(t) < l.window {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= l.limit {
+		l.hits[key] = recent
+		return false
+	}
+	l.hits[key] = append(recent, now)
+
+	// Forget keys that have gone quiet so the map doesn't grow forever
+	if len(l.hits) > 10000 {
+		for k, times := range l.hits {
+			if len(times) == 0 || now.Sub(times[len(times)-1]) >= l.window {
+				delete(l.hits, k)
+			}
+		}
+	}
+	return true
+}
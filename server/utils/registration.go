@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm"
+)
+
+const (
+	RegistrationOpen   = "open"
+	RegistrationInvite = "invite"
+	RegistrationClosed = "closed"
+)
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 20
+)
+
+var (
+	ErrRegistrationClosed = errors.New("registration is closed")
+	ErrInviteRequired     = errors.New("an invite code is required to sign up")
+	ErrInviteInvalid      = errors.New("invite code is invalid, expired or used up")
+	ErrUsernameTaken      = errors.New("username is already taken")
+)
+
+var registrationMode = RegistrationOpen
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Names that could be mistaken for the server or its staff
+var reservedUsernames = map[string]bool{
+	"admin": true, "administrator": true, "root": true, "system": true,
+	"superadmin": true, "moderator": true, "mod": true, "support": true,
+	"staff": true, "owner": true, "server": true, "me": true,
+	"null": true, "undefined": true, "anonymous": true,
+}
+
+func SetRegistrationMode(mode string) error {
+	switch mode {
+	case RegistrationOpen, RegistrationInvite, RegistrationClosed:
+		registrationMode = mode
+		return nil
+	}
+	return fmt.Errorf("unknown registration mode %q (use open, invite or closed)", mode)
+}
+
+func GetRegistrationMode() string {
+	return registrationMode
+}
+
+// ValidateUsername enforces length, charset and reserved names
+func ValidateUsername(username string) error {
+	if len(username) < minUsernameLength || len(username) > maxUsernameLength {
+		return fmt.Errorf("username must be %d to %d characters", minUsernameLength, maxUsernameLength)
+	}
+	if !usernamePattern.MatchString(username) {
+		return errors.New("username may only contain letters, digits, underscores and hyphens, and must start with a letter or digit")
+	}
+	if reservedUsernames[strings.ToLower(username)] {
+		return errors.New("username is reserved")
+	}
+	return nil
+}
+
+// CreateSignupInvite generates a new invite. A zero maxUses means unlimited
+// and a nil expiresAt never expires.
+func CreateSignupInvite(createdBy uint, maxUses int, expiresAt *time.Time, privilege int, note string) (*models.SignupInvite, error) {
+	code, err := GenerateCode(10)
+	if err != nil {
+		return nil, err
+	}
+
+	invite := models.SignupInvite{
+		Code:      code,
+		CreatedBy: createdBy,
+		Note:      note,
+		MaxUses:   maxUses,
+		ExpiresAt: expiresAt,
+		Privilege: privilege,
+	}
+	if err := database.DB.Create(&invite).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// RegisterUser creates an account according to the registration mode. When
+// an invite code is given it is consumed, even in open mode, so that its
+// preset privilege applies.
+func RegisterUser(username, passwordHash, inviteCode string) (*models.User, error) {
+	if registrationMode == RegistrationClosed {
+		return nil, ErrRegistrationClosed
+	}
+	inviteCode = NormalizeCode(inviteCode)
+	if inviteCode == "" && registrationMode == RegistrationInvite {
+		return nil, ErrInviteRequired
+	}
+
+	user := models.User{
+		Username:     username,
+		PasswordHash: passwordHash,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// The unique index is case sensitive, but names that differ only in
+		// case are too easy to confuse
+		var taken int64
+		tx.Unscoped().Model(&models.User{}).Where("LOWER(username) = LOWER(?)", username).Count(&taken)
+		if taken > 0 {
+			return ErrUsernameTaken
+		}
+
+		if inviteCode != "" {
+			var invite models.SignupInvite
+			if err := tx.Where("code = ?", inviteCode).First(&invite).Error; err != nil {
+				return ErrInviteInvalid
+			}
+
+			// Guard the use count in the update so concurrent signups can't
+			// overspend the invite
+			result := tx.Model(&models.SignupInvite{}).
+				Where("id = ? AND (max_uses = 0 OR uses < max_uses) AND (expires_at IS NULL OR expires_at > ?)",
+					invite.ID, time.Now().UTC()).
+				Update("uses", gorm.Expr("uses + 1"))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrInviteInvalid
+			}
+
+			user.Privilege = invite.Privilege
+		}
+
+		return tx.Create(&user).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+func TestSignupInviteMaxUses(t *testing.T) {
+	useTestDB(t)
+
+	tests := []struct {
+		name    string
+		maxUses int
+		signups int
+		want    int // Signups expected to get through
+	}{
+		{"single use", 1, 3, 1},
+		{"a few uses", 3, 5, 3},
+		{"unlimited", 0, 25, 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invite, err := CreateSignupInvite(1, tt.maxUses, nil, 0, "")
+			if err != nil {
+				t.Fatalf("CreateSignupInvite: %v", err)
+			}
+
+			var stored models.SignupInvite
+			database.DB.First(&stored, invite.ID)
+			if stored.MaxUses != tt.maxUses {
+				t.Fatalf("stored max_uses = %d, want %d", stored.MaxUses, tt.maxUses)
+			}
+
+			accepted := 0
+			for i := 0; i < tt.signups; i++ {
+				_, err := RegisterUser(fmt.Sprintf("user%d_%d", invite.ID, i), "hash", invite.Code)
+				switch {
+				case err == nil:
+					accepted++
+				case errors.Is(err, ErrInviteInvalid):
+				default:
+					t.Fatalf("RegisterUser: %v", err)
+				}
+			}
+			if accepted != tt.want {
+				t.Errorf("%d signups got through, want %d", accepted, tt.want)
+			}
+
+			database.DB.First(&stored, invite.ID)
+			if stored.Uses != tt.want {
+				t.Errorf("uses = %d, want %d", stored.Uses, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/config"
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"github.com/robfig/cron/v3"
+)
+
+// Cadences deciding which days get a submission window
+const (
+	CadenceDaily    = "daily"
+	CadenceWeekdays = "weekdays"
+	CadenceCron     = "cron"
+)
+
+const dateLayout = "2006-01-02"
+
+var defaultSchedule models.GameSchedule
+
+// SetDefaultSchedule configures the schedule used by games without their own
+func SetDefaultSchedule(cfg config.Config) error {
+	schedule := models.GameSchedule{
+		Timezone:  cfg.DefaultTimezone,
+		OpenStart: cfg.DefaultOpenStart,
+		OpenEnd:   cfg.DefaultOpenEnd,
+		CloseAt:   cfg.DefaultCloseAt,
+		Cadence:   cfg.DefaultCadence,
+	}
+	if _, err := CompileSchedule(&schedule); err != nil {
+		return fmt.Errorf("invalid default schedule: %w", err)
+	}
+	defaultSchedule = schedule
+	return nil
+}
+
+// GetGameSchedule returns the game's schedule, falling back to the default
+func GetGameSchedule(gameID uint) models.GameSchedule {
+	var schedule models.GameSchedule
+	if err := database.DB.Where("game_id = ?", gameID).First(&schedule).Error; err != nil {
+		schedule = defaultSchedule
+		schedule.GameID = gameID
+	}
+	return schedule
+}
+
+// Schedule is a GameSchedule parsed and ready to evaluate
+type Schedule struct {
+	Location  *time.Location
+	openStart time.Duration
+	openEnd   time.Duration
+	closeAt   time.Duration
+	cadence   string
+	cron      cron.Schedule
+	skipDates map[string]bool
+	overrides []*override
+}
+
+// CompileSchedule validates a GameSchedule and parses it for evaluation
+func CompileSchedule(s *models.GameSchedule) (*Schedule, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", s.Timezone)
+	}
+
+	openStart, err := parseClock(s.OpenStart)
+	if err != nil {
+		return nil, err
+	}
+	openEnd, err := parseClock(s.OpenEnd)
+	if err != nil {
+		return nil, err
+	}
+	if openEnd <= openStart {
+		return nil, errors.New("open end must be after open start")
+	}
+
+	closeAt := 24 * time.Hour
+	if s.CloseAt != "" {
+		if closeAt, err = parseClock(s.CloseAt); err != nil {
+			return nil, err
+		}
+		if closeAt <= openEnd {
+			return nil, errors.New("close time must be after open end")
+		}
+	}
+
+	schedule := &Schedule{
+		Location:  loc,
+		openStart: openStart,
+		openEnd:   openEnd,
+		closeAt:   closeAt,
+		cadence:   s.Cadence,
+		skipDates: make(map[string]bool),
+	}
+
+	switch s.Cadence {
+	case CadenceDaily, CadenceWeekdays:
+	case CadenceCron:
+		schedule.cron, err = cron.ParseStandard(s.CronExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown cadence %q", s.Cadence)
+	}
+
+	for _, date := range config.SplitList(s.SkipDates) {
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return nil, fmt.Errorf("invalid skip date %q", date)
+		}
+		schedule.skipDates[date] = true
+	}
+
+	return schedule, nil
+}
+
+// parseClock parses an HH:MM time of day into an offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// IsEligible reports whether the local day starting at midnight gets a window
+func (s *Schedule) IsEligible(day time.Time) bool {
+	if s.skipDates[day.Format(dateLayout)] {
+		return false
+	}
+
+	if o := s.overrideOn(day); o != nil {
+		switch o.rule.Kind {
+		case models.OverrideSkip, models.OverrideHoliday:
+			return false
+		case models.OverrideFixedTime:
+			// A fixed time puts a window on the day whatever the cadence says
+			return true
+		}
+	}
+
+	switch s.cadence {
+	case CadenceWeekdays:
+		return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+	case CadenceCron:
+		next := s.cron.Next(day.Add(-time.Nanosecond))
+		return next.Before(day.AddDate(0, 0, 1))
+	}
+
+	return true
+}
+
+// OpenRange returns the span a window may open in on the given local day.
+// A fixed time override gives an empty span at that time.
+func (s *Schedule) OpenRange(day time.Time) (time.Time, time.Time) {
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, s.Location)
+	if o := s.overrideOn(day); o != nil {
+		switch o.rule.Kind {
+		case models.OverrideFixedTime:
+			at := wallClock(midnight, o.at)
+			return at, at
+		case models.OverrideRange:
+			return wallClock(midnight, o.openStart), wallClock(midnight, o.openEnd)
+		}
+	}
+	return wallClock(midnight, s.openStart), wallClock(midnight, s.openEnd)
+}
+
+// CloseTime returns when a window opening at openTime closes: the schedule's
+// close time on the same local day, or on the next day if that has passed
+func (s *Schedule) CloseTime(openTime time.Time) time.Time {
+	day := s.LocalDay(openTime)
+	closeTime := s.closeOn(day)
+	if !closeTime.After(openTime) {
+		closeTime = s.closeOn(day.AddDate(0, 0, 1))
+	}
+	return closeTime
+}
+
+func (s *Schedule) closeOn(day time.Time) time.Time {
+	if s.closeAt == 24*time.Hour {
+		return time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, s.Location)
+	}
+	return wallClock(day, s.closeAt)
+}
+
+// wallClock adds a time of day to midnight by wall clock, so DST changes
+// don't shift the range
+func wallClock(midnight time.Time, offset time.Duration) time.Time {
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(),
+		int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, midnight.Location())
+}
+
+// LocalDay returns midnight of the day t falls on in the schedule's timezone
+func (s *Schedule) LocalDay(t time.Time) time.Time {
+	local := t.In(s.Location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.Location)
+}
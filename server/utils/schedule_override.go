@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+// override is a ScheduleOverride parsed and ready to match days against.
+// Dates are kept as UTC midnights so day arithmetic ignores DST.
+type override struct {
+	rule      models.ScheduleOverride
+	start     time.Time
+	end       time.Time
+	until     time.Time // Zero when the rule doesn't end
+	at        time.Duration
+	openStart time.Duration
+	openEnd   time.Duration
+}
+
+// compileOverride validates a ScheduleOverride and parses it for matching
+func compileOverride(rule *models.ScheduleOverride) (*override, error) {
+	o := &override{rule: *rule}
+
+	switch rule.Kind {
+	case models.OverrideSkip, models.OverrideFixedTime, models.OverrideRange:
+	case models.OverrideHoliday:
+		if rule.Name == "" {
+			return nil, errors.New("holidays need a name")
+		}
+	default:
+		return nil, fmt.Errorf("unknown override kind %q", rule.Kind)
+	}
+
+	var err error
+	if o.start, err = time.Parse(dateLayout, rule.StartDate); err != nil {
+		return nil, fmt.Errorf("invalid start date %q", rule.StartDate)
+	}
+	if rule.EndDate == "" {
+		o.end = o.start
+	} else if o.end, err = time.Parse(dateLayout, rule.EndDate); err != nil {
+		return nil, fmt.Errorf("invalid end date %q", rule.EndDate)
+	}
+	if o.end.Before(o.start) {
+		return nil, errors.New("end date must not be before start date")
+	}
+
+	switch rule.Recurrence {
+	case models.RecurNone:
+		if rule.Until != "" {
+			return nil, errors.New("until only applies to recurring overrides")
+		}
+	case models.RecurWeekly:
+		// Seven days or more would cover every day
+		if !o.end.Before(o.start.AddDate(0, 0, 6)) {
+			return nil, errors.New("weekly overrides must span less than a week")
+		}
+	case models.RecurYearly:
+		if !o.end.Before(o.start.AddDate(1, 0, -1)) {
+			return nil, errors.New("yearly overrides must span less than a year")
+		}
+	default:
+		return nil, fmt.Errorf("unknown recurrence %q", rule.Recurrence)
+	}
+
+	if rule.Until != "" {
+		if o.until, err = time.Parse(dateLayout, rule.Until); err != nil {
+			return nil, fmt.Errorf("invalid until date %q", rule.Until)
+		}
+		if o.until.Before(o.start) {
+			return nil, errors.New("until must not be before start date")
+		}
+	}
+
+	switch rule.Kind {
+	case models.OverrideFixedTime:
+		if o.at, err = parseClock(rule.Time); err != nil {
+			return nil, err
+		}
+	case models.OverrideRange:
+		if o.openStart, err = parseClock(rule.OpenStart); err != nil {
+			return nil, err
+		}
+		if o.openEnd, err = parseClock(rule.OpenEnd); err != nil {
+			return nil, err
+		}
+		if o.openEnd <= o.openStart {
+			return nil, errors.New("open end must be after open start")
+		}
+	}
+
+	return o, nil
+}
+
+// matches reports whether the rule applies on the local day
+func (o *override) matches(day time.Time) bool {
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if date.Before(o.start) || (!o.until.IsZero() && date.After(o.until)) {
+		return false
+	}
+
+	switch o.rule.Recurrence {
+	case models.RecurWeekly:
+		days := int(date.Sub(o.start).Hours() / 24)
+		return days%7 <= int(o.end.Sub(o.start).Hours()/24)
+	case models.RecurYearly:
+		from, to, on := monthDay(o.start), monthDay(o.end), monthDay(date)
+		if from <= to {
+			return from <= on && on <= to
+		}
+		// The span runs over new year
+		return on >= from || on <= to
+	}
+
+	return !date.After(o.end)
+}
+
+func monthDay(t time.Time) int {
+	return int(t.Month())*100 + t.Day()
+}
+
+// precedence orders override kinds: skipping beats a fixed time, which beats
+// a different range
+func (o *override) precedence() int {
+	switch o.rule.Kind {
+	case models.OverrideFixedTime:
+		return 1
+	case models.OverrideRange:
+		return 2
+	}
+	return 0
+}
+
+// AddOverride validates a rule against the schedule and makes the schedule
+// follow it. Of the rules matching a day, the highest precedence kind wins,
+// and among those the newest.
+func (s *Schedule) AddOverride(rule *models.ScheduleOverride) error {
+	o, err := compileOverride(rule)
+	if err != nil {
+		return err
+	}
+	if o.at >= s.closeAt || o.openEnd >= s.closeAt {
+		return errors.New("override opens the window after it would close")
+	}
+	s.overrides = append(s.overrides, o)
+	return nil
+}
+
+// overrideOn returns the rule deciding the local day, or nil if none matches
+func (s *Schedule) overrideOn(day time.Time) *override {
+	var best *override
+	for _, o := range s.overrides {
+		if !o.matches(day) {
+			continue
+		}
+		if best == nil || o.precedence() < best.precedence() ||
+			(o.precedence() == best.precedence() && o.rule.ID > best.rule.ID) {
+			best = o
+		}
+	}
+	return best
+}
+
+// OverrideOn returns the rule deciding the local day, if any
+func (s *Schedule) OverrideOn(day time.Time) (models.ScheduleOverride, bool) {
+	if o := s.overrideOn(day); o != nil {
+		return o.rule, true
+	}
+	return models.ScheduleOverride{}, false
+}
+
+// LoadSchedule compiles the game's schedule along with its override rules.
+// Rules that no longer fit the schedule are left out.
+func LoadSchedule(gameID uint) (*Schedule, error) {
+	settings := GetGameSchedule(gameID)
+	schedule, err := CompileSchedule(&settings)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []models.ScheduleOverride
+	if err := database.DB.Where("game_id = ?", gameID).Order("id asc").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("loading schedule overrides: %w", err)
+	}
+	for i := range rules {
+		if err := schedule.AddOverride(&rules[i]); err != nil {
+			fmt.Printf("Ignoring schedule override %d for game %d: %v\n", rules[i].ID, gameID, err)
+		}
+	}
+	return schedule, nil
+}
+
+// RescheduleForOverride plans the game's upcoming window again if the rule
+// matches any day up to and including the day it opens on. Windows further
+// out are left alone, so their committed open times stand.
+func RescheduleForOverride(game *models.Game, rule *models.ScheduleOverride) error {
+	o, err := compileOverride(rule)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := LoadSchedule(game.ID)
+	if err != nil {
+		return err
+	}
+
+	now := Now()
+	today := schedule.LocalDay(now)
+	last := today.AddDate(0, 0, 366)
+	if window, err := nextWindowAfter(game.ID, now); err == nil {
+		last = schedule.LocalDay(window.OpenTime)
+	}
+
+	for day := today; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if o.matches(day) {
+			return RescheduleGame(game)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+func testSchedule(t *testing.T, cadence, closeAt string) *Schedule {
+	t.Helper()
+	schedule, err := CompileSchedule(&models.GameSchedule{
+		Timezone:  "America/Chicago",
+		OpenStart: "09:00",
+		OpenEnd:   "17:00",
+		CloseAt:   closeAt,
+		Cadence:   cadence,
+	})
+	if err != nil {
+		t.Fatalf("compiling schedule: %v", err)
+	}
+	return schedule
+}
+
+func localDay(t *testing.T, s *Schedule, date string) time.Time {
+	t.Helper()
+	day, err := time.ParseInLocation(dateLayout, date, s.Location)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", date, err)
+	}
+	return day
+}
+
+func TestOverrideMatches(t *testing.T) {
+	schedule := testSchedule(t, CadenceDaily, "")
+
+	tests := []struct {
+		name string
+		rule models.ScheduleOverride
+		days map[string]bool
+	}{
+		{
+			name: "once",
+			rule: models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-03-10", EndDate: "2026-03-12"},
+			days: map[string]bool{
+				"2026-03-09": false,
+				"2026-03-10": true,
+				"2026-03-12": true,
+				"2026-03-13": false,
+				"2027-03-10": false,
+			},
+		},
+		{
+			name: "once across the spring DST change",
+			rule: models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-03-07", EndDate: "2026-03-09"},
+			days: map[string]bool{
+				"2026-03-08": true,
+				"2026-03-09": true,
+				"2026-03-10": false,
+			},
+		},
+		{
+			name: "weekly weekend until a date",
+			rule: models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-10-24", EndDate: "2026-10-25",
+				Recurrence: models.RecurWeekly, Until: "2026-11-08"},
+			days: map[string]bool{
+				"2026-10-17": false,
+				"2026-10-24": true,
+				"2026-10-25": true,
+				"2026-10-26": false,
+				"2026-10-30": false,
+				"2026-10-31": true,
+				"2026-11-01": true,
+				"2026-11-08": true,
+				"2026-11-14": false,
+			},
+		},
+		{
+			name: "yearly",
+			rule: models.ScheduleOverride{Kind: models.OverrideHoliday, Name: "Christmas", StartDate: "2026-12-24", EndDate: "2026-12-26",
+				Recurrence: models.RecurYearly},
+			days: map[string]bool{
+				"2025-12-25": false,
+				"2026-12-23": false,
+				"2026-12-25": true,
+				"2027-12-24": true,
+				"2030-12-26": true,
+				"2027-12-27": false,
+			},
+		},
+		{
+			name: "yearly across new year",
+			rule: models.ScheduleOverride{Kind: models.OverrideHoliday, Name: "New year", StartDate: "2026-12-30", EndDate: "2027-01-02",
+				Recurrence: models.RecurYearly},
+			days: map[string]bool{
+				"2026-12-29": false,
+				"2026-12-31": true,
+				"2027-01-01": true,
+				"2027-01-03": false,
+				"2027-06-01": false,
+				"2027-12-30": true,
+				"2028-01-02": true,
+				"2028-01-03": false,
+			},
+		},
+		{
+			name: "yearly until a date",
+			rule: models.ScheduleOverride{Kind: models.OverrideHoliday, Name: "Christmas", StartDate: "2026-12-25", EndDate: "2026-12-25",
+				Recurrence: models.RecurYearly, Until: "2027-12-31"},
+			days: map[string]bool{
+				"2026-12-25": true,
+				"2027-12-25": true,
+				"2028-12-25": false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := compileOverride(&tt.rule)
+			if err != nil {
+				t.Fatalf("compileOverride: %v", err)
+			}
+			for date, want := range tt.days {
+				if got := o.matches(localDay(t, schedule, date)); got != want {
+					t.Errorf("matches(%s) = %v, want %v", date, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileOverrideRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		rule models.ScheduleOverride
+	}{
+		{"unknown kind", models.ScheduleOverride{Kind: "vacation", StartDate: "2026-01-01"}},
+		{"holiday without a name", models.ScheduleOverride{Kind: models.OverrideHoliday, StartDate: "2026-01-01"}},
+		{"bad start date", models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-13-01"}},
+		{"end before start", models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-01-02", EndDate: "2026-01-01"}},
+		{"until without recurrence", models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-01-01", Until: "2026-02-01"}},
+		{"unknown recurrence", models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-01-01", Recurrence: "monthly"}},
+		{"weekly spanning a week", models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-01-01", EndDate: "2026-01-07",
+			Recurrence: models.RecurWeekly}},
+		{"yearly spanning a year", models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-01-01", EndDate: "2027-01-01",
+			Recurrence: models.RecurYearly}},
+		{"yearly covering every day", models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-01-01", EndDate: "2026-12-31",
+			Recurrence: models.RecurYearly}},
+		{"until before start", models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: "2026-01-01",
+			Recurrence: models.RecurWeekly, Until: "2025-12-31"}},
+		{"fixed time without a time", models.ScheduleOverride{Kind: models.OverrideFixedTime, StartDate: "2026-01-01"}},
+		{"range ending before it starts", models.ScheduleOverride{Kind: models.OverrideRange, StartDate: "2026-01-01",
+			OpenStart: "12:00", OpenEnd: "11:00"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := compileOverride(&tt.rule); err == nil {
+				t.Error("compileOverride accepted the rule")
+			}
+		})
+	}
+}
+
+func TestAddOverrideRejectsTimesAfterClose(t *testing.T) {
+	schedule := testSchedule(t, CadenceDaily, "20:00")
+
+	rules := []models.ScheduleOverride{
+		{Kind: models.OverrideFixedTime, StartDate: "2026-01-01", Time: "20:00"},
+		{Kind: models.OverrideRange, StartDate: "2026-01-01", OpenStart: "18:00", OpenEnd: "21:00"},
+	}
+	for _, rule := range rules {
+		if err := schedule.AddOverride(&rule); err == nil {
+			t.Errorf("AddOverride accepted %s rule opening after close", rule.Kind)
+		}
+	}
+}
+
+func TestOverridePrecedence(t *testing.T) {
+	schedule := testSchedule(t, CadenceWeekdays, "")
+
+	rules := []models.ScheduleOverride{
+		{ID: 1, Kind: models.OverrideRange, StartDate: "2026-10-19", EndDate: "2026-10-23", OpenStart: "18:00", OpenEnd: "20:00"},
+		{ID: 2, Kind: models.OverrideFixedTime, StartDate: "2026-10-20", Time: "12:00"},
+		{ID: 3, Kind: models.OverrideSkip, StartDate: "2026-10-21"},
+		{ID: 4, Kind: models.OverrideFixedTime, StartDate: "2026-10-21", Time: "13:00"},
+		{ID: 5, Kind: models.OverrideRange, StartDate: "2026-10-22", OpenStart: "10:00", OpenEnd: "11:00"},
+		{ID: 6, Kind: models.OverrideFixedTime, StartDate: "2026-10-24", Time: "08:00"},
+		{ID: 7, Kind: models.OverrideHoliday, Name: "Day off", StartDate: "2026-10-23"},
+		{ID: 8, Kind: models.OverrideFixedTime, StartDate: "2026-10-20", Time: "14:00"},
+	}
+	for i := range rules {
+		if err := schedule.AddOverride(&rules[i]); err != nil {
+			t.Fatalf("AddOverride(%d): %v", rules[i].ID, err)
+		}
+	}
+
+	tests := []struct {
+		date       string
+		rule       uint // 0 when no rule applies
+		eligible   bool
+		start, end string
+	}{
+		{"2026-10-19", 1, true, "18:00", "20:00"},
+		{"2026-10-20", 8, true, "14:00", "14:00"}, // Newest fixed time beats the older one and the range
+		{"2026-10-21", 3, false, "", ""},          // Skipping beats a fixed time
+		{"2026-10-22", 5, true, "10:00", "11:00"}, // Newest range wins
+		{"2026-10-23", 7, false, "", ""},          // Holidays skip too
+		{"2026-10-24", 6, true, "08:00", "08:00"}, // A fixed time puts a window on a Saturday
+		{"2026-10-25", 0, false, "", ""},          // Sunday, no rule
+		{"2026-10-26", 0, true, "09:00", "17:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			day := localDay(t, schedule, tt.date)
+
+			rule, ok := schedule.OverrideOn(day)
+			if ok != (tt.rule != 0) || rule.ID != tt.rule {
+				t.Errorf("OverrideOn = %d (%v), want %d", rule.ID, ok, tt.rule)
+			}
+			if got := schedule.IsEligible(day); got != tt.eligible {
+				t.Fatalf("IsEligible = %v, want %v", got, tt.eligible)
+			}
+			if !tt.eligible {
+				return
+			}
+
+			start, end := schedule.OpenRange(day)
+			if got := start.Format("15:04"); got != tt.start {
+				t.Errorf("range start = %s, want %s", got, tt.start)
+			}
+			if got := end.Format("15:04"); got != tt.end {
+				t.Errorf("range end = %s, want %s", got, tt.end)
+			}
+		})
+	}
+}
+
+func TestRescheduleForOverride(t *testing.T) {
+	useTestDB(t)
+
+	game := models.Game{Name: "test", InviteCode: "RESCHEDULE"}
+	if err := database.DB.Create(&game).Error; err != nil {
+		t.Fatalf("creating game: %v", err)
+	}
+	if err := database.DB.Create(&models.GameSchedule{
+		GameID: game.ID, Timezone: "UTC", OpenStart: "09:00", OpenEnd: "17:00", Cadence: CadenceDaily,
+	}).Error; err != nil {
+		t.Fatalf("creating schedule: %v", err)
+	}
+
+	// The upcoming window opens in two days
+	upcoming := PlanWindow(game.ID, time.Now().UTC().AddDate(0, 0, 2).Truncate(24*time.Hour).Add(10*time.Hour))
+	if err := database.DB.Create(&upcoming).Error; err != nil {
+		t.Fatalf("creating window: %v", err)
+	}
+
+	date := func(days int) string {
+		return time.Now().UTC().AddDate(0, 0, days).Format(dateLayout)
+	}
+
+	tests := []struct {
+		name    string
+		rule    models.ScheduleOverride
+		replans bool
+	}{
+		{"rule past the upcoming window", models.ScheduleOverride{Kind: models.OverrideSkip, StartDate: date(5)}, false},
+		{"rule on the upcoming window's day", models.ScheduleOverride{Kind: models.OverrideRange, StartDate: date(2),
+			OpenStart: "12:00", OpenEnd: "13:00"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before models.SubmissionWindow
+			database.DB.Where("game_id = ?", game.ID).Order("id desc").First(&before)
+
+			if err := RescheduleForOverride(&game, &tt.rule); err != nil {
+				t.Fatalf("RescheduleForOverride: %v", err)
+			}
+
+			var after models.SubmissionWindow
+			database.DB.Where("game_id = ?", game.ID).Order("id desc").First(&after)
+			if replanned := after.ID != before.ID; replanned != tt.replans {
+				t.Errorf("replanned = %v, want %v", replanned, tt.replans)
+			}
+		})
+	}
+}
@@ -2,81 +2,110 @@ package utils
 
 import (
 	"fmt"
-	"math/rand"
 	"time"
 
+	"github.com/bluefalconhd/lbd_game/server/config"
 	"github.com/bluefalconhd/lbd_game/server/database"
 	"github.com/bluefalconhd/lbd_game/server/models"
 	"github.com/robfig/cron/v3"
 )
 
-var (
-	cst *time.Location
-)
+// Jobs the scheduler runs. Window jobs replay hour by hour after downtime so
+// missed days still get their window, scored in order.
+var jobs = []*Job{
+	{Name: "windows", Period: time.Minute, CatchUp: time.Hour, Run: runWindowJobs},
+	// Windows nobody claims by the deadline get a phrase from the bank. Not
+	// replayed, since nobody could have said a phrase while we were down.
+	{Name: "phrase_bank", Period: time.Minute, Run: FillUnclaimedWindows},
+	{Name: "old_windows", Period: 24 * time.Hour, Run: CleanupOldWindows},
+	{Name: "sessions", Period: time.Hour, RealTime: true, Run: CleanupSessions},
+	{Name: "failed_logins", Period: 24 * time.Hour, RealTime: true, Run: CleanupFailedLogins},
+	{Name: "job_runs", Period: 24 * time.Hour, RealTime: true, Run: CleanupJobRuns},
+}
 
-func init() {
-	var err error
-	cst, err = time.LoadLocation("America/Chicago")
-	if err != nil {
+func InitScheduler(cfg config.Config) {
+	if err := SetEliminationRules(cfg.EliminationRules); err != nil {
+		panic(err)
+	}
+	if err := SetDefaultSchedule(cfg); err != nil {
 		panic(err)
 	}
-}
 
-func InitScheduler() {
-	c := cron.New(cron.WithLocation(cst))
-	// Schedule at midnight CST
-	c.AddFunc("0 0 * * *", scheduleSubmissionWindow)
+	normalizeWindowTimes()
+	backfillWindowPhases()
 
-	// Run immediately if there's no window for today
-	today := time.Now().In(cst).Truncate(24 * time.Hour)
-	var window models.SubmissionWindow
-	if database.DB.Where("date = ?", today).First(&window).Error != nil {
-		scheduleSubmissionWindow()
+	// Make up for runs missed while the server was down before taking new
+	// ones, so new and restarted games aren't left without a window
+	for _, job := range jobs {
+		CatchUpJob(job, job.now())
 	}
 
+	c := cron.New()
+	for _, job := range jobs {
+		job := job
+		c.AddFunc("@every "+job.Period.String(), func() {
+			RunJob(job, job.now(), false)
+		})
+	}
 	c.Start()
 }
 
-func IsSubmissionWindowOpen() bool {
-	now := time.Now().In(cst)
-
-	// Get most recent submission window
-	var window models.SubmissionWindow
-	if err := database.DB.Order("open_time desc").First(&window).Error; err != nil {
-		return false
+// runWindowJobs plans new windows, moves windows through their phases and
+// scores the ones that closed, all as of now
+func runWindowJobs(now time.Time) error {
+	if err := scheduleSubmissionWindow(now); err != nil {
+		return err
+	}
+	if err := AdvanceWindows(now); err != nil {
+		return err
 	}
+	return RunEliminationPass(now)
+}
 
-	// Check if we've passed the open time
-	if now.Before(window.OpenTime) {
-		return false
+// normalizeWindowTimes rewrites open times stored before all times were UTC
+func normalizeWindowTimes() {
+	var windows []models.SubmissionWindow
+	if err := database.DB.Unscoped().Where("open_time NOT LIKE ?", "%+00:00").Find(&windows).Error; err != nil {
+		fmt.Printf("Failed to fetch windows to normalize: %v\n", err)
+		return
 	}
 
-	// Check if a phrase has already been submitted for this window
-	var phrase models.Phrase
-	if err := database.DB.Where("submission_window = ?", window.ID).First(&phrase).Error; err == nil {
-		return false
+	for _, window := range windows {
+		database.DB.Unscoped().Model(&window).UpdateColumn("open_time", window.OpenTime.UTC())
 	}
+}
 
-	return true
+func IsSubmissionWindowOpen(gameID uint) bool {
+	window, err := GetCurrentWindow(gameID)
+	return err == nil && window.Phase == models.WindowOpen
 }
 
-func randomTime(start, end time.Time) time.Time {
-	delta := end.Sub(start)
-	sec := rand.Int63n(int64(delta.Seconds()))
-	return start.Add(time.Duration(sec) * time.Second)
+// GetCurrentWindow returns the newest window that has opened, in the phase
+// it should be in by now. Windows scheduled but not open yet are skipped.
+func GetCurrentWindow(gameID uint) (*models.SubmissionWindow, error) {
+	return currentWindowAt(gameID, Now())
 }
 
-func GetCurrentWindow() (*models.SubmissionWindow, error) {
+func currentWindowAt(gameID uint, now time.Time) (*models.SubmissionWindow, error) {
 	var window models.SubmissionWindow
-	if err := database.DB.Order("open_time desc").First(&window).Error; err != nil {
+	if err := database.DB.Where("game_id = ? AND open_time <= ?", gameID, now.UTC()).
+		Order("open_time desc").
+		First(&window).Error; err != nil {
+		return nil, err
+	}
+	if err := syncWindowPhase(&window, now); err != nil {
 		return nil, err
 	}
 	return &window, nil
 }
 
-func GetNextScheduledWindow() (*models.SubmissionWindow, error) {
+func GetNextScheduledWindow(gameID uint) (*models.SubmissionWindow, error) {
+	return nextWindowAfter(gameID, Now())
+}
+
+func nextWindowAfter(gameID uint, t time.Time) (*models.SubmissionWindow, error) {
 	var window models.SubmissionWindow
-	if err := database.DB.Where("open_time > ?", time.Now()).
+	if err := database.DB.Where("game_id = ? AND open_time > ?", gameID, t.UTC()).
 		Order("open_time asc").
 		First(&window).Error; err != nil {
 		return nil, err
@@ -84,39 +113,121 @@ func GetNextScheduledWindow() (*models.SubmissionWindow, error) {
 	return &window, nil
 }
 
-func CleanupOldWindows() error {
-	// Keep only the last 30 days of windows
-	cutoff := time.Now().AddDate(0, 0, -30)
+// CleanupOldWindows deletes windows older than 30 days that nothing happened
+// in. Windows with phrases, verifications or eliminations are history and
+// are kept.
+func CleanupOldWindows(now time.Time) error {
+	cutoff := now.UTC().AddDate(0, 0, -30)
 	return database.DB.Unscoped().
 		Where("open_time < ?", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM phrases WHERE phrases.submission_window = submission_windows.id)").
+		Where("NOT EXISTS (SELECT 1 FROM verifications WHERE verifications.submission_window = submission_windows.id)").
+		Where("NOT EXISTS (SELECT 1 FROM eliminations WHERE eliminations.submission_window = submission_windows.id)").
 		Delete(&models.SubmissionWindow{}).Error
 }
 
-// Modify the existing scheduleSubmissionWindow function
-func scheduleSubmissionWindow() {
+func scheduleSubmissionWindow(now time.Time) error {
+	var games []models.Game
+	if err := database.DB.Find(&games).Error; err != nil {
+		return fmt.Errorf("fetching games: %w", err)
+	}
+
+	for i := range games {
+		scheduleGameWindow(&games[i], now)
+	}
+	return nil
+}
+
+// RescheduleGame cancels a game's unopened windows and plans the next one
+// under its current schedule. Cancelled windows are only soft deleted, so
+// every commitment ever published stays open to audit.
+func RescheduleGame(game *models.Game) error {
+	if err := database.DB.
+		Where("game_id = ? AND open_time > ?", game.ID, Now()).
+		Delete(&models.SubmissionWindow{}).Error; err != nil {
+		return err
+	}
+
+	scheduleGameWindow(game, Now())
+	return nil
+}
+
+// scheduleGameWindow schedules the game's next window unless one is already
+// waiting to open as of now.
+func scheduleGameWindow(game *models.Game, now time.Time) {
 	// Check if there's already a window scheduled
-	if window, err := GetNextScheduledWindow(); err == nil && window != nil {
+	if window, err := nextWindowAfter(game.ID, now); err == nil && window != nil {
 		// Already have a scheduled window
 		return
 	}
 
-	now := time.Now().In(cst)
-	tomorrow := now.Add(24 * time.Hour).Truncate(24 * time.Hour)
-
-	// Generate random time between 4:30 AM and 8:20 AM CST for tomorrow
-	startRange := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 4, 30, 0, 0, cst)
-	endRange := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 8, 20, 0, 0, cst)
-	openTime := randomTime(startRange, endRange)
+	schedule, err := LoadSchedule(game.ID)
+	if err != nil {
+		fmt.Printf("Invalid schedule for game %d: %v\n", game.ID, err)
+		return
+	}
 
-	window := models.SubmissionWindow{
-		OpenTime: openTime,
+	start, end, ok := nextOpenRange(game.ID, schedule, now)
+	if !ok {
+		// Nothing eligible in the coming year
+		return
 	}
 
+	window, err := PlanFairWindow(game.ID, start, end)
+	if err != nil {
+		fmt.Printf("Failed to draw an open time for game %d: %v\n", game.ID, err)
+		return
+	}
 	if err := database.DB.Create(&window).Error; err != nil {
 		// Log the error appropriately
-		fmt.Printf("Failed to schedule submission window: %v\n", err)
+		fmt.Printf("Failed to schedule submission window for game %d: %v\n", game.ID, err)
+	} else {
+		TriggerUpdate(game.ID, UpdateWindow)
 	}
+}
+
+// nextOpenRange returns the open range left on the first eligible local day
+// that doesn't already have a window and whose open range hasn't passed. The
+// range starts no earlier than the game's previous window closes.
+func nextOpenRange(gameID uint, schedule *Schedule, now time.Time) (time.Time, time.Time, bool) {
+	today := schedule.LocalDay(now)
+
+	for i := 0; i <= 366; i++ {
+		day := today.AddDate(0, 0, i)
+		if !schedule.IsEligible(day) {
+			continue
+		}
+
+		start, end := schedule.OpenRange(day)
+		if !end.After(now) {
+			continue
+		}
+		if start.Before(now) {
+			start = now
+		}
+		// Don't open while a window placed by hand is still running
+		if busy := busyUntil(database.DB, gameID, end); busy.After(start) {
+			if !end.After(busy) {
+				continue
+			}
+			start = busy
+		}
+
+		if hasWindowOn(gameID, day) {
+			continue
+		}
+
+		return start, end, true
+	}
+
+	return time.Time{}, time.Time{}, false
+}
 
-	// Cleanup old windows
-	CleanupOldWindows()
+// hasWindowOn reports whether the game already has a window on the local day
+func hasWindowOn(gameID uint, day time.Time) bool {
+	var count int64
+	database.DB.Model(&models.SubmissionWindow{}).
+		Where("game_id = ? AND open_time >= ? AND open_time < ?", gameID, day.UTC(), day.AddDate(0, 0, 1).UTC()).
+		Count(&count)
+	return count > 0
 }
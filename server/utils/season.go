@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSeasonRunning = errors.New("a season is already running")
+	ErrTooFewPlayers = errors.New("a season needs at least two members")
+	ErrSeasonEnded   = errors.New("the season has already ended")
+)
+
+// Standing is a member's position within a season
+type Standing struct {
+	Rank                  int        `json:"rank"`
+	UserID                uint       `json:"user_id"`
+	Username              string     `json:"username"`
+	IsEliminated          bool       `json:"is_eliminated"`
+	EliminatedAt          *time.Time `json:"eliminated_at"`
+	VerificationsReceived int64      `json:"verifications_received"`
+	VerificationsGiven    int64      `json:"verifications_given"`
+	PhrasesSubmitted      int64      `json:"phrases_submitted"`
+}
+
+func GetActiveSeason(gameID uint) (*models.Season, error) {
+	var season models.Season
+	if err := database.DB.Where("game_id = ? AND ended_at IS NULL", gameID).First(&season).Error; err != nil {
+		return nil, err
+	}
+	return &season, nil
+}
+
+// StartSeason begins a new season, bringing every member back into the game.
+// Windows that haven't opened yet become part of the season. A season with a
+// single member would be won on the spot, so it takes at least two.
+func StartSeason(gameID uint, name string, endsAt *time.Time) (*models.Season, error) {
+	now := Now()
+	season := models.Season{
+		GameID:    gameID,
+		Name:      name,
+		StartedAt: now,
+		EndsAt:    endsAt,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Checked in the transaction so two concurrent starts can't both pass
+		var running int64
+		if err := tx.Model(&models.Season{}).Where("game_id = ? AND ended_at IS NULL", gameID).Count(&running).Error; err != nil {
+			return err
+		}
+		if running > 0 {
+			return ErrSeasonRunning
+		}
+
+		var members int64
+		if err := tx.Model(&models.GameMember{}).Where("game_id = ?", gameID).Count(&members).Error; err != nil {
+			return err
+		}
+		if members < 2 {
+			return ErrTooFewPlayers
+		}
+
+		if err := tx.Create(&season).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.GameMember{}).
+			Where("game_id = ?", gameID).
+			Updates(map[string]interface{}{
+				"is_eliminated":      false,
+				"eliminated_at":      nil,
+				"elimination_reason": "",
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.SubmissionWindow{}).
+			Where("game_id = ? AND open_time > ?", gameID, now).
+			Update("season_id", season.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	TriggerUpdate(gameID, UpdateSeason)
+
+	return &season, nil
+}
+
+// ComputeStandings ranks a game's members within a season. Survivors rank
+// first, then members by how late they were eliminated, with verifications
+// received breaking ties. Members still tied share a rank.
+func ComputeStandings(gameID, seasonID uint) ([]Standing, error) {
+	received := database.DB.Table("verifications").
+		Select("verifications.verified_user_id as user_id, COUNT(*) as total").
+		Joins("JOIN submission_windows ON submission_windows.id = verifications.submission_window").
+		Where("submission_windows.season_id = ? AND verifications.status = ? AND verifications.deleted_at IS NULL",
+			seasonID, models.VerificationConfirmed).
+		Group("verifications.verified_user_id")
+
+	given := database.DB.Table("verifications").
+		Select("verifications.verifier_id as user_id, COUNT(*) as total").
+		Joins("JOIN submission_windows ON submission_windows.id = verifications.submission_window").
+		Where("submission_windows.season_id = ? AND verifications.status = ? AND verifications.deleted_at IS NULL",
+			seasonID, models.VerificationConfirmed).
+		Group("verifications.verifier_id")
+
+	phrases := database.DB.Table("phrases").
+		Select("phrases.submitted_by as user_id, COUNT(*) as total").
+		Joins("JOIN submission_windows ON submission_windows.id = phrases.submission_window").
+		Where("submission_windows.season_id = ? AND phrases.deleted_at IS NULL", seasonID).
+		Group("phrases.submitted_by")
+
+	var standings []Standing
+	if err := database.DB.Table("game_members").
+		Select("game_members.user_id, users.username, game_members.is_eliminated, game_members.eliminated_at, "+
+			"COALESCE(received.total, 0) as verifications_received, "+
+			"COALESCE(given.total, 0) as verifications_given, "+
+			"COALESCE(phrases.total, 0) as phrases_submitted, "+
+			"RANK() OVER (ORDER BY game_members.is_eliminated asc, game_members.eliminated_at desc, "+
+			"COALESCE(received.total, 0) desc) as rank").
+		Joins("JOIN users ON users.id = game_members.user_id AND users.deleted_at IS NULL").
+		Joins("LEFT JOIN (?) as received ON received.user_id = game_members.user_id", received).
+		Joins("LEFT JOIN (?) as given ON given.user_id = game_members.user_id", given).
+		Joins("LEFT JOIN (?) as phrases ON phrases.user_id = game_members.user_id", phrases).
+		Where("game_members.game_id = ?", gameID).
+		Order("game_members.is_eliminated asc, game_members.eliminated_at desc, verifications_received desc, users.username asc").
+		Find(&standings).Error; err != nil {
+		return nil, err
+	}
+
+	return standings, nil
+}
+
+// EndSeason closes the season and archives its final standings. Only the
+// first of two concurrent calls ends it, the other gets ErrSeasonEnded.
+func EndSeason(season *models.Season, winnerID *uint, reason string) error {
+	standings, err := ComputeStandings(season.GameID, season.ID)
+	if err != nil {
+		return err
+	}
+
+	now := Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Ending the season first claims it, so the results are archived once
+		ended := tx.Model(&models.Season{}).
+			Where("id = ? AND ended_at IS NULL", season.ID).
+			Updates(map[string]interface{}{
+				"ended_at":   now,
+				"end_reason": reason,
+				"winner_id":  winnerID,
+			})
+		if ended.Error != nil {
+			return ended.Error
+		}
+		if ended.RowsAffected == 0 {
+			return ErrSeasonEnded
+		}
+
+		for _, standing := range standings {
+			result := models.SeasonResult{
+				SeasonID:              season.ID,
+				UserID:                standing.UserID,
+				Rank:                  standing.Rank,
+				IsEliminated:          standing.IsEliminated,
+				EliminatedAt:          standing.EliminatedAt,
+				VerificationsReceived: standing.VerificationsReceived,
+				VerificationsGiven:    standing.VerificationsGiven,
+				PhrasesSubmitted:      standing.PhrasesSubmitted,
+			}
+			if err := tx.Create(&result).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	season.EndedAt = &now
+	season.EndReason = reason
+	season.WinnerID = winnerID
+
+	TriggerUpdate(season.GameID, UpdateSeason)
+
+	return nil
+}
+
+// CheckSeasonEnds ends running seasons that have a single survivor, no
+// survivors or have passed their planned end.
+func CheckSeasonEnds(now time.Time) {
+	var seasons []models.Season
+	if err := database.DB.Where("ended_at IS NULL").Find(&seasons).Error; err != nil {
+		fmt.Printf("Failed to fetch running seasons: %v\n", err)
+		return
+	}
+
+	now = now.UTC()
+	for i := range seasons {
+		season := &seasons[i]
+
+		var survivors []uint
+		if err := database.DB.Model(&models.GameMember{}).
+			Where("game_id = ? AND is_eliminated = ?", season.GameID, false).
+			Pluck("user_id", &survivors).Error; err != nil {
+			fmt.Printf("Failed to count survivors in game %d: %v\n", season.GameID, err)
+			continue
+		}
+
+		var winnerID *uint
+		var reason string
+		switch {
+		case len(survivors) == 1:
+			winnerID = &survivors[0]
+			reason = models.SeasonEndWinner
+		case len(survivors) == 0:
+			reason = models.SeasonEndNoSurvivors
+		case season.EndsAt != nil && !now.Before(*season.EndsAt):
+			reason = models.SeasonEndExpired
+		default:
+			continue
+		}
+
+		if err := EndSeason(season, winnerID, reason); err != nil && !errors.Is(err, ErrSeasonEnded) {
+			fmt.Printf("Failed to end season %d: %v\n", season.ID, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+func TestStartSeason(t *testing.T) {
+	useTestDB(t)
+
+	lonely := createTestGame(t)
+	createTestMembers(t, lonely.ID, 1)
+	if _, err := StartSeason(lonely.ID, "alone", nil); !errors.Is(err, ErrTooFewPlayers) {
+		t.Errorf("StartSeason with one member = %v, want ErrTooFewPlayers", err)
+	}
+
+	game := createTestGame(t)
+	members := createTestMembers(t, game.ID, 2)
+	database.DB.Model(&models.GameMember{}).Where("user_id = ?", members[0]).Update("is_eliminated", true)
+
+	if _, err := StartSeason(game.ID, "first", nil); err != nil {
+		t.Fatalf("StartSeason: %v", err)
+	}
+	if _, err := StartSeason(game.ID, "second", nil); !errors.Is(err, ErrSeasonRunning) {
+		t.Errorf("StartSeason while running = %v, want ErrSeasonRunning", err)
+	}
+
+	var eliminated int64
+	database.DB.Model(&models.GameMember{}).Where("game_id = ? AND is_eliminated = ?", game.ID, true).Count(&eliminated)
+	if eliminated != 0 {
+		t.Errorf("%d members still eliminated after the season started", eliminated)
+	}
+}
+
+func TestEndSeasonOnce(t *testing.T) {
+	useTestDB(t)
+
+	tests := []struct {
+		name string
+		ends int
+	}{
+		{"ended twice in a row", 1},
+		{"ended concurrently", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := createTestGame(t)
+			createTestMembers(t, game.ID, 3)
+			season, err := StartSeason(game.ID, tt.name, nil)
+			if err != nil {
+				t.Fatalf("StartSeason: %v", err)
+			}
+
+			errs := make([]error, tt.ends+1)
+			var wg sync.WaitGroup
+			for i := 0; i < tt.ends; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					stale := *season
+					errs[i] = EndSeason(&stale, nil, models.SeasonEndAdmin)
+				}(i)
+			}
+			wg.Wait()
+			errs[tt.ends] = EndSeason(season, nil, models.SeasonEndExpired)
+
+			ended := 0
+			for _, err := range errs {
+				switch {
+				case err == nil:
+					ended++
+				case errors.Is(err, ErrSeasonEnded):
+				default:
+					t.Fatalf("EndSeason: %v", err)
+				}
+			}
+			if ended != 1 {
+				t.Errorf("season ended %d times, want once", ended)
+			}
+
+			var results int64
+			database.DB.Model(&models.SeasonResult{}).Where("season_id = ?", season.ID).Count(&results)
+			if results != 3 {
+				t.Errorf("archived %d results, want 3", results)
+			}
+
+			var stored models.Season
+			database.DB.First(&stored, season.ID)
+			if stored.EndedAt == nil || stored.EndReason != models.SeasonEndAdmin {
+				t.Errorf("season ended at %v for %q, want ended by an admin", stored.EndedAt, stored.EndReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm"
+)
+
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrSessionInvalid = errors.New("session is invalid or has expired")
+	ErrTokenReused    = errors.New("refresh token has already been used")
+)
+
+// TokenPair is what a client receives when signing in or refreshing
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Seconds until the access token expires
+	SessionID    uint   `json:"session_id"`
+}
+
+func newRefreshToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func issueTokens(user *models.User, session *models.Session, refreshToken string) (*TokenPair, error) {
+	accessToken, err := GenerateToken(user.ID, user.Privilege, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(AccessTokenTTL / time.Second),
+		SessionID:    session.ID,
+	}, nil
+}
+
+// CreateSession signs a user in on a new device
+func CreateSession(user *models.User, userAgent, ipAddress string) (*TokenPair, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	session := models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		UserAgent:        userAgent,
+		IPAddress:        ipAddress,
+		ExpiresAt:        now.Add(RefreshTokenTTL),
+		LastUsedAt:       now,
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	return issueTokens(user, &session, refreshToken)
+}
+
+// RefreshSession exchanges a refresh token for a new token pair. Refresh
+// tokens are single use: presenting a rotated-out token revokes the session,
+// since either the client or an attacker is holding a stale copy.
+func RefreshSession(refreshToken string) (*TokenPair, error) {
+	hash := hashToken(refreshToken)
+	now := time.Now().UTC()
+
+	var pair *TokenPair
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		if err := tx.Where("refresh_token_hash = ?", hash).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				var reused int64
+				tx.Model(&models.Session{}).
+					Where("previous_token_hash = ? AND revoked_at IS NULL", hash).
+					Count(&reused)
+				if reused > 0 {
+					return ErrTokenReused
+				}
+				return ErrSessionInvalid
+			}
+			return err
+		}
+
+		if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+			return ErrSessionInvalid
+		}
+
+		var user models.User
+		if err := tx.First(&user, session.UserID).Error; err != nil {
+			return ErrSessionInvalid
+		}
+
+		next, err := newRefreshToken()
+		if err != nil {
+			return err
+		}
+
+		// Guard on the old hash so two concurrent refreshes can't both succeed
+		result := tx.Model(&models.Session{}).
+			Where("id = ? AND refresh_token_hash = ?", session.ID, hash).
+			Updates(map[string]interface{}{
+				"refresh_token_hash":  hashToken(next),
+				"previous_token_hash": hash,
+				"expires_at":          now.Add(RefreshTokenTTL),
+				"last_used_at":        now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSessionInvalid
+		}
+
+		pair, err = issueTokens(&user, &session, next)
+		return err
+	})
+	if err == ErrTokenReused {
+		database.DB.Model(&models.Session{}).Where("previous_token_hash = ?", hash).Update("revoked_at", now)
+	}
+	return pair, err
+}
+
+// AuthenticateSession checks that the session behind an access token is still
+// live and returns the user's current privilege. The privilege claim in the
+// token is not trusted, so demotions apply on the next request.
+func AuthenticateSession(userID, sessionID uint) (int, error) {
+	var row struct {
+		Privilege int
+	}
+	err := database.DB.Table("sessions").
+		Select("users.privilege").
+		Joins("JOIN users ON users.id = sessions.user_id AND users.deleted_at IS NULL").
+		Where("sessions.id = ? AND sessions.user_id = ? AND sessions.revoked_at IS NULL AND sessions.expires_at > ?",
+			sessionID, userID, time.Now().UTC()).
+		Take(&row).Error
+	if err != nil {
+		return 0, ErrSessionInvalid
+	}
+	return row.Privilege, nil
+}
+
+// RevokeSession signs a single device out
+func RevokeSession(userID, sessionID uint) error {
+	result := database.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionInvalid
+	}
+	return nil
+}
+
+// RevokeUserSessions signs a user out everywhere
+func RevokeUserSessions(userID uint) error {
+	return database.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now().UTC()).Error
+}
+
+// GetActiveSessions lists the devices a user is signed in on
+func GetActiveSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := database.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now().UTC()).
+		Order("last_used_at desc").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// CleanupSessions deletes sessions that can no longer be used
+func CleanupSessions(now time.Time) error {
+	cutoff := now.UTC()
+	return database.DB.
+		Where("expires_at < ? OR revoked_at < ?", cutoff, cutoff.Add(-RefreshTokenTTL)).
+		Delete(&models.Session{}).Error
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bluefalconhd/lbd_game/server/config"
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+// useTestJWT signs tokens for the test with the given settings, restoring the
+// previous keys afterwards
+func useTestJWT(t *testing.T, cfg config.Config) {
+	t.Helper()
+
+	previousKey, previousKeys := signingKey, jwtKeys
+	t.Cleanup(func() { signingKey, jwtKeys = previousKey, previousKeys })
+
+	if cfg.JWTAlgorithm == "" {
+		cfg.JWTAlgorithm = "HS256"
+	}
+	if err := InitJWT(cfg); err != nil {
+		t.Fatalf("InitJWT: %v", err)
+	}
+}
+
+func createTestUser(t *testing.T, password string) *models.User {
+	t.Helper()
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		t.Fatalf("hashing password: %v", err)
+	}
+	testUsers++
+	user := models.User{Username: fmt.Sprintf("user%d", testUsers), PasswordHash: hash}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	return &user
+}
+
+func TestRefreshSessionRotates(t *testing.T) {
+	useTestDB(t)
+	useTestJWT(t, config.Config{JWTKeyID: "test", JWTSecret: "secret"})
+
+	user := createTestUser(t, "password")
+	first, err := CreateSession(user, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	claims, err := VerifyToken(first.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyToken: %v", err)
+	}
+	if claims.UserID != user.ID || claims.SessionID != first.SessionID {
+		t.Errorf("token for user %d session %d, want user %d session %d", claims.UserID, claims.SessionID, user.ID, first.SessionID)
+	}
+
+	second, err := RefreshSession(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshSession: %v", err)
+	}
+	if second.SessionID != first.SessionID || second.RefreshToken == first.RefreshToken {
+		t.Error("refreshing didn't rotate the refresh token within the session")
+	}
+	if _, err := AuthenticateSession(user.ID, second.SessionID); err != nil {
+		t.Errorf("AuthenticateSession after refreshing: %v", err)
+	}
+
+	// Replaying the rotated-out token gives the session away, so it ends
+	if _, err := RefreshSession(first.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("refreshing with a used token = %v, want ErrTokenReused", err)
+	}
+	if _, err := RefreshSession(second.RefreshToken); !errors.Is(err, ErrSessionInvalid) {
+		t.Errorf("refreshing a revoked session = %v, want ErrSessionInvalid", err)
+	}
+	if _, err := AuthenticateSession(user.ID, second.SessionID); !errors.Is(err, ErrSessionInvalid) {
+		t.Errorf("AuthenticateSession on a revoked session = %v, want ErrSessionInvalid", err)
+	}
+
+	if _, err := RefreshSession("made up"); !errors.Is(err, ErrSessionInvalid) {
+		t.Errorf("refreshing with an unknown token = %v, want ErrSessionInvalid", err)
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	useTestDB(t)
+	useTestJWT(t, config.Config{JWTKeyID: "test", JWTSecret: "secret"})
+
+	user := createTestUser(t, "password")
+	other := createTestUser(t, "password")
+
+	var sessions []*TokenPair
+	for i := 0; i < 3; i++ {
+		pair, err := CreateSession(user, "test", "127.0.0.1")
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		sessions = append(sessions, pair)
+	}
+	otherSession, _ := CreateSession(other, "test", "127.0.0.1")
+
+	if err := RevokeSession(other.ID, sessions[0].SessionID); !errors.Is(err, ErrSessionInvalid) {
+		t.Errorf("revoking someone else's session = %v, want ErrSessionInvalid", err)
+	}
+	if err := RevokeSession(user.ID, sessions[0].SessionID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if err := RevokeSession(user.ID, sessions[0].SessionID); !errors.Is(err, ErrSessionInvalid) {
+		t.Errorf("revoking twice = %v, want ErrSessionInvalid", err)
+	}
+
+	active, _ := GetActiveSessions(user.ID)
+	if len(active) != 2 {
+		t.Errorf("%d active sessions after revoking one of three, want 2", len(active))
+	}
+
+	if err := RevokeUserSessions(user.ID); err != nil {
+		t.Fatalf("RevokeUserSessions: %v", err)
+	}
+	active, _ = GetActiveSessions(user.ID)
+	if len(active) != 0 {
+		t.Errorf("%d active sessions after signing out everywhere, want 0", len(active))
+	}
+	if _, err := AuthenticateSession(other.ID, otherSession.SessionID); err != nil {
+		t.Errorf("another user's session was revoked: %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+// VerificationRef names the other side of a verification
+type VerificationRef struct {
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	Status     string    `json:"status"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// WindowHistory is what happened to one user in one window
+type WindowHistory struct {
+	WindowID        uint              `json:"window_id"`
+	OpenTime        time.Time         `json:"open_time"`
+	Phrase          *string           `json:"phrase"`
+	PhraseBy        *string           `json:"phrase_by"`
+	SubmittedPhrase bool              `json:"submitted_phrase"`
+	VerifiedBy      []VerificationRef `json:"verified_by"`
+	Verified        []VerificationRef `json:"verified"`
+}
+
+type UserStats struct {
+	UserID                uint       `json:"user_id"`
+	Username              string     `json:"username"`
+	IsEliminated          bool       `json:"is_eliminated"`
+	EliminatedAt          *time.Time `json:"eliminated_at"`
+	EliminationReason     string     `json:"elimination_reason"`
+	WindowsPlayed         int64      `json:"windows_played"`
+	VerificationsReceived int64      `json:"verifications_received"`
+	VerificationsGiven    int64      `json:"verifications_given"`
+	PhrasesSubmitted      int64      `json:"phrases_submitted"`
+	CurrentStreak         int        `json:"current_streak"`
+	LongestStreak         int        `json:"longest_streak"`
+}
+
+// GetUserHistory returns every opened window of the game the member took part
+// in, newest first. That is every window since they joined plus any earlier
+// window they were active in.
+func GetUserHistory(member *models.GameMember) ([]WindowHistory, error) {
+	now := Now()
+
+	active := database.DB.Table("verifications").
+		Select("submission_window").
+		Where("game_id = ? AND (verified_user_id = ? OR verifier_id = ?) AND deleted_at IS NULL",
+			member.GameID, member.UserID, member.UserID)
+
+	var rows []struct {
+		WindowID    uint
+		OpenTime    time.Time
+		Phrase      *string
+		SubmittedBy *uint
+		PhraseBy    *string
+	}
+	if err := database.DB.Table("submission_windows").
+		Select("submission_windows.id as window_id, submission_windows.open_time, phrases.content as phrase, "+
+			"phrases.submitted_by, users.username as phrase_by").
+		Joins("LEFT JOIN phrases ON phrases.submission_window = submission_windows.id AND phrases.deleted_at IS NULL").
+		Joins("LEFT JOIN users ON users.id = phrases.submitted_by").
+		Where("submission_windows.game_id = ? AND submission_windows.open_time <= ? AND submission_windows.deleted_at IS NULL",
+			member.GameID, now).
+		Where("submission_windows.open_time >= ? OR submission_windows.id IN (?) OR phrases.submitted_by = ?",
+			member.CreatedAt, active, member.UserID).
+		Order("submission_windows.open_time desc").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var verifications []struct {
+		SubmissionWindow uint
+		VerifiedUserID   uint
+		VerifiedName     string
+		VerifierID       uint
+		VerifierName     string
+		Status           string
+		CreatedAt        time.Time
+	}
+	if err := database.DB.Table("verifications").
+		Select("verifications.submission_window, verifications.verified_user_id, u1.username as verified_name, "+
+			"verifications.verifier_id, u2.username as verifier_name, verifications.status, verifications.created_at").
+		Joins("JOIN users u1 ON u1.id = verifications.verified_user_id").
+		Joins("JOIN users u2 ON u2.id = verifications.verifier_id").
+		Where("verifications.game_id = ? AND verifications.deleted_at IS NULL", member.GameID).
+		Where("verifications.verified_user_id = ? OR verifications.verifier_id = ?", member.UserID, member.UserID).
+		Order("verifications.created_at asc").
+		Find(&verifications).Error; err != nil {
+		return nil, err
+	}
+
+	history := make([]WindowHistory, len(rows))
+	index := make(map[uint]int, len(rows))
+	for i, row := range rows {
+		history[i] = WindowHistory{
+			WindowID:        row.WindowID,
+			OpenTime:        row.OpenTime,
+			Phrase:          row.Phrase,
+			PhraseBy:        row.PhraseBy,
+			SubmittedPhrase: row.SubmittedBy != nil && *row.SubmittedBy == member.UserID,
+			VerifiedBy:      []VerificationRef{},
+			Verified:        []VerificationRef{},
+		}
+		index[row.WindowID] = i
+	}
+
+	for _, v := range verifications {
+		i, ok := index[v.SubmissionWindow]
+		if !ok {
+			continue
+		}
+		if v.VerifiedUserID == member.UserID {
+			history[i].VerifiedBy = append(history[i].VerifiedBy, VerificationRef{v.VerifierID, v.VerifierName, v.Status, v.CreatedAt})
+		}
+		if v.VerifierID == member.UserID {
+			history[i].Verified = append(history[i].Verified, VerificationRef{v.VerifiedUserID, v.VerifiedName, v.Status, v.CreatedAt})
+		}
+	}
+
+	return history, nil
+}
+
+// GetUserStats aggregates a member's record in a game
+func GetUserStats(member *models.GameMember) (*UserStats, error) {
+	stats := UserStats{
+		UserID:            member.UserID,
+		IsEliminated:      member.IsEliminated,
+		EliminatedAt:      member.EliminatedAt,
+		EliminationReason: member.EliminationReason,
+	}
+
+	var user models.User
+	if err := database.DB.Select("username").First(&user, member.UserID).Error; err != nil {
+		return nil, err
+	}
+	stats.Username = user.Username
+
+	var counts struct {
+		Received int64
+		Given    int64
+	}
+	if err := database.DB.Table("verifications").
+		Select("COALESCE(SUM(CASE WHEN verified_user_id = ? THEN 1 ELSE 0 END), 0) as received, "+
+			"COALESCE(SUM(CASE WHEN verifier_id = ? THEN 1 ELSE 0 END), 0) as given", member.UserID, member.UserID).
+		Where("game_id = ? AND status = ? AND deleted_at IS NULL", member.GameID, models.VerificationConfirmed).
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	stats.VerificationsReceived = counts.Received
+	stats.VerificationsGiven = counts.Given
+
+	if err := database.DB.Model(&models.Phrase{}).
+		Where("game_id = ? AND submitted_by = ?", member.GameID, member.UserID).
+		Count(&stats.PhrasesSubmitted).Error; err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Model(&models.SubmissionWindow{}).
+		Where("game_id = ? AND open_time >= ? AND open_time <= ?", member.GameID, member.CreatedAt, Now()).
+		Count(&stats.WindowsPlayed).Error; err != nil {
+		return nil, err
+	}
+
+	current, longest, err := verificationStreaks(member.GameID, member.UserID)
+	if err != nil {
+		return nil, err
+	}
+	stats.CurrentStreak = current
+	stats.LongestStreak = longest
+
+	return &stats, nil
+}
+
+// verificationStreaks returns the user's current and longest runs of
+// consecutive verified windows. Windows without a phrase don't count either
+// way, and a running window only counts once the user is verified in it.
+func verificationStreaks(gameID, userID uint) (int, int, error) {
+	var windows []struct {
+		Verified bool
+		ScoredAt *time.Time
+	}
+	if err := database.DB.Table("submission_windows").
+		Select("EXISTS (SELECT 1 FROM verifications WHERE verifications.submission_window = submission_windows.id "+
+			"AND verifications.verified_user_id = ? AND verifications.status = ? AND verifications.deleted_at IS NULL) as verified, "+
+			"submission_windows.scored_at", userID, models.VerificationConfirmed).
+		Where("submission_windows.game_id = ? AND submission_windows.open_time <= ? AND submission_windows.deleted_at IS NULL",
+			gameID, Now()).
+		Where("EXISTS (SELECT 1 FROM phrases WHERE phrases.submission_window = submission_windows.id AND phrases.deleted_at IS NULL)").
+		Order("submission_windows.open_time asc").
+		Find(&windows).Error; err != nil {
+		return 0, 0, err
+	}
+
+	// A running window the user hasn't been verified in yet can't break a streak
+	if n := len(windows); n > 0 && !windows[n-1].Verified && windows[n-1].ScoredAt == nil {
+		windows = windows[:n-1]
+	}
+
+	current, longest := 0, 0
+	for _, window := range windows {
+		if window.Verified {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else {
+			current = 0
+		}
+	}
+
+	return current, longest, nil
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm"
+)
+
+// ErrPhraseTaken means another phrase won the window
+var ErrPhraseTaken = errors.New("a phrase has already been submitted for this window")
+
+// ClaimWindow stores content as the open window's phrase if nobody got there
+// first and locks the window. The phrase is stamped as it is inserted, so
+// whoever gets in first also has the earliest submit time. When the window is
+// taken the winning phrase is returned with ErrPhraseTaken and the losing
+// attempt is recorded. Either way the time of the claim is returned.
+func ClaimWindow(window *models.SubmissionWindow, userID uint, content string) (*models.Phrase, time.Time, error) {
+	phrase := models.Phrase{
+		GameID:           window.GameID,
+		Content:          content,
+		SubmittedBy:      userID,
+		SubmissionWindow: window.ID,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Claims take the write lock up front, so stamping here keeps submit
+		// times in the order the claims are made
+		phrase.CreatedAt = Now()
+
+		// The window may have been cancelled since the caller looked it up
+		if err := tx.Select("id").First(&models.SubmissionWindow{}, window.ID).Error; err != nil {
+			return err
+		}
+		// The unique index on the window rejects all but one concurrent insert
+		if err := tx.Create(&phrase).Error; err != nil {
+			return err
+		}
+		return lockWindow(tx, window, phrase.CreatedAt)
+	})
+	if err == nil {
+		return &phrase, phrase.CreatedAt, nil
+	}
+	attemptedAt := Now()
+	if errors.Is(err, ErrWrongPhase) {
+		return nil, attemptedAt, err
+	}
+
+	var winner models.Phrase
+	if database.DB.Where("submission_window = ?", window.ID).First(&winner).Error != nil {
+		return nil, attemptedAt, err
+	}
+
+	if err := database.DB.Create(&models.SubmissionAttempt{
+		GameID:           window.GameID,
+		SubmissionWindow: window.ID,
+		UserID:           userID,
+		Content:          content,
+		AttemptedAt:      attemptedAt,
+		BeatenByMs:       attemptedAt.Sub(winner.CreatedAt).Milliseconds(),
+	}).Error; err != nil {
+		fmt.Printf("Failed to record losing submission for window %d: %v\n", window.ID, err)
+	}
+
+	return &winner, attemptedAt, ErrPhraseTaken
+}
+
+// WinningMargin returns how many milliseconds the phrase beat the first
+// losing attempt by, or nil when nobody else tried. Attempts from before the
+// phrase was claimed lost to an earlier phrase that has since been removed.
+func WinningMargin(phrase *models.Phrase) *int64 {
+	var attempt models.SubmissionAttempt
+	if err := database.DB.Where("submission_window = ? AND attempted_at >= ?", phrase.SubmissionWindow, phrase.CreatedAt).
+		Order("attempted_at asc").
+		First(&attempt).Error; err != nil {
+		return nil
+	}
+	margin := attempt.AttemptedAt.Sub(phrase.CreatedAt).Milliseconds()
+	return &margin
+}
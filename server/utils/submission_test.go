@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+func TestClaimWindowRace(t *testing.T) {
+	useTestDB(t)
+
+	tests := []struct {
+		name     string
+		claimers int
+	}{
+		{"single claim", 1},
+		{"two at once", 2},
+		{"crowd", 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := createTestWindow(t, models.WindowOpen, Now().Add(-time.Minute))
+
+			type result struct {
+				phrase    *models.Phrase
+				claimedAt time.Time
+				err       error
+			}
+			results := make([]result, tt.claimers)
+
+			var wg sync.WaitGroup
+			start := make(chan struct{})
+			for i := range results {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-start
+					phrase, claimedAt, err := ClaimWindow(window, uint(i+1), fmt.Sprintf("phrase %d", i))
+					results[i] = result{phrase, claimedAt, err}
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+
+			var winner *models.Phrase
+			for i, r := range results {
+				switch {
+				case r.err == nil:
+					if winner != nil {
+						t.Fatalf("claims %d and %d both won", winner.SubmittedBy, i+1)
+					}
+					winner = r.phrase
+				case errors.Is(r.err, ErrPhraseTaken):
+				default:
+					t.Fatalf("claim %d failed: %v", i+1, r.err)
+				}
+			}
+			if winner == nil {
+				t.Fatal("no claim won")
+			}
+
+			for i, r := range results {
+				if r.err == nil {
+					continue
+				}
+				if r.phrase.ID != winner.ID {
+					t.Errorf("claim %d was told phrase %d won, want %d", i+1, r.phrase.ID, winner.ID)
+				}
+				if r.claimedAt.Before(winner.CreatedAt) {
+					t.Errorf("claim %d lost at %s, before the winner at %s", i+1, r.claimedAt, winner.CreatedAt)
+				}
+			}
+
+			var attempts []models.SubmissionAttempt
+			database.DB.Where("submission_window = ?", window.ID).Find(&attempts)
+			if len(attempts) != tt.claimers-1 {
+				t.Errorf("recorded %d losing attempts, want %d", len(attempts), tt.claimers-1)
+			}
+			for _, attempt := range attempts {
+				if attempt.BeatenByMs < 0 {
+					t.Errorf("attempt by %d beaten by %dms", attempt.UserID, attempt.BeatenByMs)
+				}
+			}
+
+			var stored models.SubmissionWindow
+			database.DB.First(&stored, window.ID)
+			if stored.Phase != models.WindowLocked || stored.LockedAt == nil || !stored.LockedAt.Equal(winner.CreatedAt) {
+				t.Errorf("window phase %s locked at %v, want locked at %s", stored.Phase, stored.LockedAt, winner.CreatedAt)
+			}
+		})
+	}
+}
+
+func TestClaimWindowWrongPhase(t *testing.T) {
+	useTestDB(t)
+
+	for _, phase := range []string{models.WindowVerifying, models.WindowClosed, models.WindowScored} {
+		t.Run(phase, func(t *testing.T) {
+			window := createTestWindow(t, phase, Now().Add(-time.Hour))
+
+			if _, _, err := ClaimWindow(window, 1, "too late"); !errors.Is(err, ErrWrongPhase) {
+				t.Fatalf("ClaimWindow = %v, want ErrWrongPhase", err)
+			}
+
+			var count int64
+			database.DB.Model(&models.Phrase{}).Where("submission_window = ?", window.ID).Count(&count)
+			if count != 0 {
+				t.Errorf("%d phrases stored for a %s window", count, phase)
+			}
+		})
+	}
+}
+
+func TestClaimAfterReopen(t *testing.T) {
+	useTestDB(t)
+
+	window := createTestWindow(t, models.WindowOpen, Now().Add(-time.Minute))
+
+	if _, _, err := ClaimWindow(window, 1, "first"); err != nil {
+		t.Fatalf("first claim: %v", err)
+	}
+	if _, _, err := ClaimWindow(window, 2, "too slow"); !errors.Is(err, ErrPhraseTaken) {
+		t.Fatalf("second claim = %v, want ErrPhraseTaken", err)
+	}
+
+	if err := ReopenWindow(window); err != nil {
+		t.Fatalf("ReopenWindow: %v", err)
+	}
+	var attempts int64
+	database.DB.Model(&models.SubmissionAttempt{}).Where("submission_window = ?", window.ID).Count(&attempts)
+	if attempts != 0 {
+		t.Errorf("%d attempts left over after reopening", attempts)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	phrase, _, err := ClaimWindow(window, 3, "second")
+	if err != nil {
+		t.Fatalf("claim after reopening: %v", err)
+	}
+	if margin := WinningMargin(phrase); margin != nil {
+		t.Errorf("won by %dms with nobody else trying", *margin)
+	}
+
+	if _, _, err := ClaimWindow(window, 4, "slow again"); !errors.Is(err, ErrPhraseTaken) {
+		t.Fatalf("claim after the new winner = %v, want ErrPhraseTaken", err)
+	}
+	if margin := WinningMargin(phrase); margin == nil || *margin < 0 {
+		t.Errorf("won by %v, want a margin of at least 0", margin)
+	}
+}
@@ -10,6 +10,10 @@ import (
 func GetBearerToken(c *gin.Context) (string, error) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
+		// EventSource cannot set headers, so the event stream passes the token in the query
+		if token := c.Query("token"); token != "" && c.GetHeader("Accept") == "text/event-stream" {
+			return token, nil
+		}
 		return "", errors.New("Authorization header required")
 	}
 
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/bluefalconhd/lbd_game/server/config"
+)
+
+var (
+	ErrUploadTooLarge = errors.New("file is too large")
+	ErrUploadType     = errors.New("only images and audio clips are accepted")
+)
+
+// Evidence types as detected from file contents, with the extension used to
+// store them. Browsers record audio as webm or mp4.
+var evidenceTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"audio/mpeg":      ".mp3",
+	"audio/wave":      ".wav",
+	"audio/aiff":      ".aiff",
+	"application/ogg": ".ogg",
+	"video/webm":      ".webm",
+	"video/mp4":       ".m4a",
+}
+
+var (
+	uploadDir     = "uploads"
+	maxUploadSize = int64(10 << 20)
+)
+
+// InitUploads prepares the directory evidence files are stored in
+func InitUploads(cfg config.Config) error {
+	uploadDir = cfg.UploadDir
+	maxUploadSize = cfg.MaxUploadSize
+	return os.MkdirAll(uploadDir, 0o755)
+}
+
+// SaveEvidence stores an uploaded evidence file under a random name and
+// returns that name along with the detected content type.
+func SaveEvidence(header *multipart.FileHeader) (string, string, error) {
+	if header.Size > maxUploadSize {
+		return "", "", ErrUploadTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	// Trust the contents rather than the client's declared type
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", "", err
+	}
+	mimeType := http.DetectContentType(sniff[:n])
+	ext, ok := evidenceTypes[mimeType]
+	if !ok {
+		return "", "", ErrUploadType
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return "", "", err
+	}
+	storedName := hex.EncodeToString(name) + ext
+
+	out, err := os.OpenFile(EvidencePath(storedName), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", "", err
+	}
+	defer out.Close()
+
+	// Guard against the declared size understating the real one
+	written, err := io.Copy(out, io.LimitReader(file, maxUploadSize+1))
+	if err == nil && written > maxUploadSize {
+		err = ErrUploadTooLarge
+	}
+	if err != nil {
+		out.Close()
+		RemoveEvidence(storedName)
+		return "", "", err
+	}
+
+	return storedName, mimeType, nil
+}
+
+func EvidencePath(storedName string) string {
+	return filepath.Join(uploadDir, filepath.Base(storedName))
+}
+
+func RemoveEvidence(storedName string) {
+	if err := os.Remove(EvidencePath(storedName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Failed to remove evidence file %s: %v\n", storedName, err)
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm"
+)
+
+// SettleVerification moves a verification to confirmed once it has enough
+// confirmations, or back to pending when it doesn't.
+func SettleVerification(tx *gorm.DB, verification *models.Verification) error {
+	var game models.Game
+	if err := tx.Select("required_confirmations").First(&game, verification.GameID).Error; err != nil {
+		return err
+	}
+
+	var confirmations int64
+	if err := tx.Model(&models.VerificationConfirmation{}).
+		Where("verification_id = ?", verification.ID).
+		Count(&confirmations).Error; err != nil {
+		return err
+	}
+
+	status := models.VerificationPending
+	if confirmations >= int64(game.RequiredConfirmations) {
+		status = models.VerificationConfirmed
+	}
+
+	if status == verification.Status {
+		return nil
+	}
+
+	verification.Status = status
+	return tx.Model(verification).Update("status", status).Error
+}
+
+// HasStandingVerification reports whether the player has a verification in
+// the window that hasn't been rejected
+func HasStandingVerification(windowID, userID uint) bool {
+	var count int64
+	database.DB.Model(&models.Verification{}).
+		Where("submission_window = ? AND verified_user_id = ? AND status <> ?", windowID, userID, models.VerificationRejected).
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+func TestOneStandingVerificationPerWindow(t *testing.T) {
+	useTestDB(t)
+
+	window := createTestWindow(t, models.WindowVerifying, Now().Add(-time.Hour))
+	verify := func(verifierID uint) error {
+		return database.DB.Create(&models.Verification{
+			GameID: window.GameID, VerifiedUserID: 1, VerifierID: verifierID,
+			SubmissionWindow: window.ID, Status: models.VerificationPending,
+		}).Error
+	}
+
+	if err := verify(2); err != nil {
+		t.Fatalf("first verification: %v", err)
+	}
+	if !HasStandingVerification(window.ID, 1) {
+		t.Error("HasStandingVerification = false after verifying")
+	}
+	if err := verify(3); err == nil {
+		t.Fatal("second verifier verified the same player in the window")
+	}
+
+	database.DB.Model(&models.Verification{}).Where("submission_window = ?", window.ID).
+		Update("status", models.VerificationRejected)
+	if HasStandingVerification(window.ID, 1) {
+		t.Error("HasStandingVerification = true with only a rejected verification")
+	}
+	if err := verify(3); err != nil {
+		t.Errorf("verifying again after a rejection: %v", err)
+	}
+}
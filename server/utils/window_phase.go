@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrWrongPhase means the window isn't in the phase the action needs
+	ErrWrongPhase = errors.New("the window is not in the right phase")
+	// ErrWindowOverlap means a window would open before the previous one closes
+	ErrWindowOverlap = errors.New("another window is still running at that time")
+)
+
+// PlanWindow builds a window opening at openTime, closing as the game's
+// schedule says and belonging to the running season
+func PlanWindow(gameID uint, openTime time.Time) models.SubmissionWindow {
+	window := models.SubmissionWindow{
+		GameID:    gameID,
+		Phase:     models.WindowScheduled,
+		OpenTime:  openTime.UTC(),
+		CreatedAt: Now(),
+	}
+
+	settings := GetGameSchedule(gameID)
+	if schedule, err := CompileSchedule(&settings); err == nil {
+		window.CloseTime = schedule.CloseTime(openTime).UTC()
+	} else {
+		window.CloseTime = window.OpenTime.Add(24 * time.Hour)
+	}
+
+	if season, err := GetActiveSeason(gameID); err == nil {
+		window.SeasonID = season.ID
+	}
+	return window
+}
+
+// busyUntil returns when the last of the game's windows opening at or before
+// t closes, or the zero time if there are none
+func busyUntil(tx *gorm.DB, gameID uint, t time.Time) time.Time {
+	var window models.SubmissionWindow
+	if err := tx.Where("game_id = ? AND open_time <= ?", gameID, t.UTC()).
+		Order("close_time desc").
+		First(&window).Error; err != nil {
+		return time.Time{}
+	}
+	return window.CloseTime
+}
+
+// FitWindow makes sure a window placed by hand runs on its own: it may not
+// open while an earlier window is still running, and it closes no later than
+// the next window opens. Windows only ever show one at a time, so an overlap
+// would leave the earlier one impossible to verify.
+func FitWindow(tx *gorm.DB, window *models.SubmissionWindow) error {
+	if busyUntil(tx, window.GameID, window.OpenTime).After(window.OpenTime) {
+		return ErrWindowOverlap
+	}
+
+	var next models.SubmissionWindow
+	if err := tx.Where("game_id = ? AND open_time > ?", window.GameID, window.OpenTime).
+		Order("open_time asc").
+		First(&next).Error; err == nil && next.OpenTime.Before(window.CloseTime) {
+		window.CloseTime = next.OpenTime
+	}
+	return nil
+}
+
+// AdvanceWindows moves every unfinished window that has opened into the
+// phase it should be in by now
+func AdvanceWindows(now time.Time) error {
+	var windows []models.SubmissionWindow
+	if err := database.DB.Where("phase NOT IN ? AND open_time <= ?",
+		[]string{models.WindowClosed, models.WindowScored}, now.UTC()).
+		Order("open_time asc").
+		Find(&windows).Error; err != nil {
+		return fmt.Errorf("fetching windows to advance: %w", err)
+	}
+
+	for i := range windows {
+		if err := syncWindowPhase(&windows[i], now); err != nil {
+			fmt.Printf("Failed to advance window %d: %v\n", windows[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// SyncWindowPhase applies the transitions that are due for the window and
+// saves them. Each transition is stamped with the time it was due, not when
+// it was noticed.
+func SyncWindowPhase(window *models.SubmissionWindow) error {
+	return syncWindowPhase(window, Now())
+}
+
+func syncWindowPhase(window *models.SubmissionWindow, now time.Time) error {
+	now = now.UTC()
+	start := window.Phase
+
+	for {
+		phase, at, ok := nextPhase(window, now)
+		if !ok {
+			break
+		}
+
+		column := map[string]string{
+			models.WindowOpen:      "opened_at",
+			models.WindowLocked:    "locked_at",
+			models.WindowVerifying: "verifying_at",
+			models.WindowClosed:    "closed_at",
+		}[phase]
+
+		// Only move on from the phase we read, in case a request got there first
+		result := database.DB.Model(&models.SubmissionWindow{}).
+			Where("id = ? AND phase = ?", window.ID, window.Phase).
+			Updates(map[string]interface{}{"phase": phase, column: at})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := database.DB.First(window, window.ID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		window.Phase = phase
+		switch phase {
+		case models.WindowOpen:
+			window.OpenedAt = &at
+		case models.WindowLocked:
+			window.LockedAt = &at
+		case models.WindowVerifying:
+			window.VerifyingAt = &at
+		case models.WindowClosed:
+			window.ClosedAt = &at
+		}
+	}
+
+	if window.Phase != start {
+		TriggerUpdate(window.GameID, UpdateWindow)
+	}
+	return nil
+}
+
+// nextPhase returns the phase the window moves to next and when it was due,
+// if that has happened by now
+func nextPhase(window *models.SubmissionWindow, now time.Time) (string, time.Time, bool) {
+	closing := !now.Before(window.CloseTime)
+
+	switch window.Phase {
+	case models.WindowScheduled:
+		if !now.Before(window.OpenTime) {
+			return models.WindowOpen, window.OpenTime, true
+		}
+	case models.WindowOpen:
+		// Claims lock the window themselves, but catch any that didn't
+		var phrase models.Phrase
+		if err := database.DB.Where("submission_window = ?", window.ID).First(&phrase).Error; err == nil {
+			return models.WindowLocked, phrase.CreatedAt, true
+		}
+		if closing {
+			return models.WindowClosed, window.CloseTime, true
+		}
+	case models.WindowLocked:
+		var lock int
+		database.DB.Model(&models.Game{}).Where("id = ?", window.GameID).Select("phrase_lock").Scan(&lock)
+		verifyAt := now
+		if window.LockedAt != nil {
+			verifyAt = window.LockedAt.Add(time.Duration(lock) * time.Minute)
+		}
+		// A window caught up late still passes through verifying if the lock
+		// ran out before it closed
+		if !now.Before(verifyAt) && verifyAt.Before(window.CloseTime) {
+			return models.WindowVerifying, verifyAt, true
+		}
+		if closing {
+			return models.WindowClosed, window.CloseTime, true
+		}
+	case models.WindowVerifying:
+		if closing {
+			return models.WindowClosed, window.CloseTime, true
+		}
+	}
+
+	return "", time.Time{}, false
+}
+
+// lockWindow records that the open window's phrase was claimed at the given
+// time. It fails with ErrWrongPhase once the window has moved on.
+func lockWindow(tx *gorm.DB, window *models.SubmissionWindow, at time.Time) error {
+	result := tx.Model(&models.SubmissionWindow{}).
+		Where("id = ? AND phase IN ?", window.ID, []string{models.WindowScheduled, models.WindowOpen}).
+		Updates(map[string]interface{}{"phase": models.WindowLocked, "locked_at": at})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWrongPhase
+	}
+	return nil
+}
+
+// ReopenWindow removes the locked window's phrase so another can be claimed.
+// Attempts that lost to it go too, since they didn't lose to the next phrase.
+func ReopenWindow(window *models.SubmissionWindow) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.SubmissionWindow{}).
+			Where("id = ? AND phase = ?", window.ID, models.WindowLocked).
+			Updates(map[string]interface{}{"phase": models.WindowOpen, "locked_at": nil})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrWrongPhase
+		}
+		if err := tx.Unscoped().Where("submission_window = ?", window.ID).Delete(&models.Phrase{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("submission_window = ?", window.ID).Delete(&models.SubmissionAttempt{}).Error
+	})
+}
+
+// backfillWindowPhases gives windows created before phases existed a close
+// time and marks the scored ones as such. The rest catch up on the next
+// AdvanceWindows.
+func backfillWindowPhases() {
+	var windows []models.SubmissionWindow
+	if err := database.DB.Where("close_time IS NULL OR close_time <= open_time").Find(&windows).Error; err != nil {
+		fmt.Printf("Failed to fetch windows to backfill: %v\n", err)
+		return
+	}
+
+	for _, window := range windows {
+		updates := map[string]interface{}{"close_time": PlanWindow(window.GameID, window.OpenTime).CloseTime}
+		if window.ScoredAt != nil {
+			updates["phase"] = models.WindowScored
+			updates["closed_at"] = *window.ScoredAt
+		}
+		if err := database.DB.Model(&window).UpdateColumns(updates).Error; err != nil {
+			fmt.Printf("Failed to backfill window %d: %v\n", window.ID, err)
+		}
+	}
+}
+
+// GetWindow loads a window in the phase it should be in by now
+func GetWindow(id uint) (*models.SubmissionWindow, error) {
+	var window models.SubmissionWindow
+	if err := database.DB.First(&window, id).Error; err != nil {
+		return nil, err
+	}
+	if err := SyncWindowPhase(&window); err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
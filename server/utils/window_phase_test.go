@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluefalconhd/lbd_game/server/database"
+	"github.com/bluefalconhd/lbd_game/server/models"
+)
+
+func TestSyncWindowPhase(t *testing.T) {
+	useTestDB(t)
+
+	open := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	closing := open.Add(10 * time.Hour)
+	noTime := time.Time{}
+
+	tests := []struct {
+		name      string
+		start     string
+		claimedAt *time.Duration // After open; nil when nobody claimed
+		lock      int            // Game's phrase lock in minutes
+		now       time.Duration  // After open
+		want      string
+		stamps    map[string]time.Time // Transition stamps expected, zero for unset
+	}{
+		{
+			name: "not open yet", start: models.WindowScheduled, lock: 5, now: -time.Minute,
+			want: models.WindowScheduled,
+		},
+		{
+			name: "opens", start: models.WindowScheduled, lock: 5, now: time.Minute,
+			want:   models.WindowOpen,
+			stamps: map[string]time.Time{"opened_at": open, "locked_at": noTime},
+		},
+		{
+			name: "claimed, lock running", start: models.WindowScheduled, claimedAt: minutes(2), lock: 5, now: 3 * time.Minute,
+			want:   models.WindowLocked,
+			stamps: map[string]time.Time{"opened_at": open, "locked_at": open.Add(2 * time.Minute), "verifying_at": noTime},
+		},
+		{
+			name: "claimed, lock over", start: models.WindowScheduled, claimedAt: minutes(2), lock: 5, now: 10 * time.Minute,
+			want:   models.WindowVerifying,
+			stamps: map[string]time.Time{"locked_at": open.Add(2 * time.Minute), "verifying_at": open.Add(7 * time.Minute)},
+		},
+		{
+			name: "no lock", start: models.WindowScheduled, claimedAt: minutes(2), lock: 0, now: 2 * time.Minute,
+			want:   models.WindowVerifying,
+			stamps: map[string]time.Time{"verifying_at": open.Add(2 * time.Minute)},
+		},
+		{
+			name: "nobody claimed by close", start: models.WindowOpen, lock: 5, now: 11 * time.Hour,
+			want:   models.WindowClosed,
+			stamps: map[string]time.Time{"locked_at": noTime, "closed_at": closing},
+		},
+		{
+			name: "claimed, lock outlasts close", start: models.WindowScheduled, claimedAt: minutes(599), lock: 5, now: 11 * time.Hour,
+			want:   models.WindowClosed,
+			stamps: map[string]time.Time{"verifying_at": noTime, "closed_at": closing},
+		},
+		{
+			name: "verifying until close", start: models.WindowScheduled, claimedAt: minutes(2), lock: 5, now: 10 * time.Hour,
+			want:   models.WindowClosed,
+			stamps: map[string]time.Time{"verifying_at": open.Add(7 * time.Minute), "closed_at": closing},
+		},
+		{
+			name: "closed stays closed", start: models.WindowClosed, lock: 5, now: 20 * time.Hour,
+			want: models.WindowClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := createTestWindow(t, tt.start, open)
+			database.DB.Model(&models.Game{}).Where("id = ?", window.GameID).Update("phrase_lock", tt.lock)
+
+			if tt.claimedAt != nil {
+				claimedAt := open.Add(*tt.claimedAt)
+				if err := database.DB.Create(&models.Phrase{
+					GameID: window.GameID, Content: "claimed", SubmittedBy: 1, SubmissionWindow: window.ID, CreatedAt: claimedAt,
+				}).Error; err != nil {
+					t.Fatalf("creating phrase: %v", err)
+				}
+			}
+
+			if err := syncWindowPhase(window, open.Add(tt.now)); err != nil {
+				t.Fatalf("syncWindowPhase: %v", err)
+			}
+
+			var stored models.SubmissionWindow
+			database.DB.First(&stored, window.ID)
+			if stored.Phase != tt.want || window.Phase != tt.want {
+				t.Fatalf("phase = %s (stored %s), want %s", window.Phase, stored.Phase, tt.want)
+			}
+
+			got := map[string]*time.Time{
+				"opened_at":    stored.OpenedAt,
+				"locked_at":    stored.LockedAt,
+				"verifying_at": stored.VerifyingAt,
+				"closed_at":    stored.ClosedAt,
+			}
+			for column, want := range tt.stamps {
+				switch {
+				case want.IsZero() && got[column] != nil:
+					t.Errorf("%s = %s, want unset", column, got[column])
+				case !want.IsZero() && (got[column] == nil || !got[column].Equal(want)):
+					t.Errorf("%s = %v, want %s", column, got[column], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLockAndReopenNeedTheRightPhase(t *testing.T) {
+	useTestDB(t)
+
+	open := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		phase     string
+		lockErr   error
+		reopenErr error
+	}{
+		{models.WindowScheduled, nil, ErrWrongPhase},
+		{models.WindowOpen, nil, ErrWrongPhase},
+		{models.WindowLocked, ErrWrongPhase, nil},
+		{models.WindowVerifying, ErrWrongPhase, ErrWrongPhase},
+		{models.WindowClosed, ErrWrongPhase, ErrWrongPhase},
+		{models.WindowScored, ErrWrongPhase, ErrWrongPhase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phase, func(t *testing.T) {
+			window := createTestWindow(t, tt.phase, open)
+			if err := lockWindow(database.DB, window, open.Add(time.Minute)); err != tt.lockErr {
+				t.Errorf("lockWindow = %v, want %v", err, tt.lockErr)
+			}
+
+			window = createTestWindow(t, tt.phase, open)
+			if err := ReopenWindow(window); err != tt.reopenErr {
+				t.Errorf("ReopenWindow = %v, want %v", err, tt.reopenErr)
+			}
+		})
+	}
+}
+
+func minutes(n int) *time.Duration {
+	d := time.Duration(n) * time.Minute
+	return &d
+}